@@ -0,0 +1,97 @@
+package restgo
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"sync/atomic"
+)
+
+const headerAuthorization = "Authorization"
+
+// Authenticator 认证器，每次发送请求（含重试）前对最终的http请求进行认证
+type Authenticator interface {
+	Authenticate(ctx context.Context, req *http.Request) error
+}
+
+// UnauthorizedHandler 认证器可选实现的接口，收到401响应时调用
+// 返回true时重新认证并重发一次请求（请求body需可重复读取）
+type UnauthorizedHandler interface {
+	HandleUnauthorized(ctx context.Context, req *http.Request, rsp *http.Response) bool
+}
+
+// AuthenticatorFunc 函数形式的认证器
+type AuthenticatorFunc func(ctx context.Context, req *http.Request) error
+
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, req *http.Request) error {
+	return f(ctx, req)
+}
+
+// TokenSource 提供访问令牌
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc 函数形式的TokenSource
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// BasicAuth HTTP Basic认证
+func BasicAuth(username, password string) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, req *http.Request) error {
+		req.SetBasicAuth(username, password)
+		return nil
+	})
+}
+
+// BearerToken 使用固定令牌的Bearer认证
+func BearerToken(token string) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, req *http.Request) error {
+		req.Header.Set(headerAuthorization, "Bearer "+token)
+		return nil
+	})
+}
+
+// BearerTokenSource 每次请求从TokenSource获取令牌的Bearer认证
+func BearerTokenSource(ts TokenSource) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, req *http.Request) error {
+		var token, err = ts.Token(ctx)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(headerAuthorization, "Bearer "+token)
+		return nil
+	})
+}
+
+// APIKeyHeader 通过header携带API key
+func APIKeyHeader(name, key string) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, req *http.Request) error {
+		req.Header.Set(name, key)
+		return nil
+	})
+}
+
+// APIKeyQuery 通过URL query携带API key
+func APIKeyQuery(name, key string) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, req *http.Request) error {
+		var q = req.URL.Query()
+		q.Set(name, key)
+		req.URL.RawQuery = q.Encode()
+		return nil
+	})
+}
+
+var authSeq uint64
+
+// newAuthID 为每次设置的认证器分配进程内唯一的标识，用于区分不同凭证的请求
+func newAuthID() uint64 {
+	return atomic.AddUint64(&authSeq, 1)
+}
+
+func basicCredentials(username, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+}
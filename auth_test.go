@@ -0,0 +1,38 @@
+package restgo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Authenticator(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get(headerAuthorization) + "|" + r.URL.Query().Get("key")))
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithAuthenticator(BearerTokenSource(TokenSourceFunc(func(ctx context.Context) (string, error) {
+		return "token", nil
+	}))))
+	var cases = []struct {
+		opts   []RequestOption
+		expect string
+	}{
+		{expect: "Bearer token|"},
+		{opts: []RequestOption{WithoutAuth()}, expect: "|"},
+		{opts: []RequestOption{WithRequestAuthenticator(APIKeyQuery("key", "secret"))}, expect: "|secret"},
+		{opts: []RequestOption{WithRequestAuthenticator(BasicAuth("user", "pass"))}, expect: "Basic dXNlcjpwYXNz|"},
+	}
+	for _, cs := range cases {
+		var rsp, err = c.Do(context.Background(), NewRequest("GET", "/"), cs.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data []byte
+		data, err = rsp.Data()
+		if err != nil || string(data) != cs.expect {
+			t.Errorf("expect %s, got %q %v", cs.expect, data, err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package restgo
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// BodyFunc 每次调用返回一个新的、从头开始读取的body
+type BodyFunc func() (io.ReadCloser, error)
+
+// ReplayableBody 可重复读取的请求body
+// 发送请求时会设置http.Request的GetBody和ContentLength，重定向和重试时可以重新发送body
+type ReplayableBody struct {
+	getBody BodyFunc
+	size    int64
+	reader  io.ReadCloser
+	// closer underlying source such as *os.File, closed by Client.Do after the last attempt
+	closer io.Closer
+}
+
+// NewReplayableBody size为body长度，小于0表示未知
+func NewReplayableBody(size int64, getBody BodyFunc) *ReplayableBody {
+	return &ReplayableBody{getBody: getBody, size: size}
+}
+
+// NewBytesBody 由字节数组构造可重复读取的body
+func NewBytesBody(data []byte) *ReplayableBody {
+	return NewReplayableBody(int64(len(data)), func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+}
+
+// NewSeekerBody 由io.ReadSeeker构造可重复读取的body，从当前位置开始读取
+// seeker实现io.ReaderAt（例如*os.File）时每次重放使用独立的io.SectionReader，
+// 否则所有重放共享同一个读取位置：transport在超时（RetryPolicy.AttemptTimeout）或307/308重定向后
+// 可能仍在读取上一次的body，此时不能安全地重放，这类seeker不应与AttemptTimeout一起使用
+// seeker实现io.Closer（例如*os.File）时，Client.Do在请求（含重试）结束后关闭它
+func NewSeekerBody(seeker io.ReadSeeker) (*ReplayableBody, error) {
+	var start, err = seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	var end int64
+	end, err = seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	_, err = seeker.Seek(start, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	var getBody = func() (io.ReadCloser, error) {
+		var _, sErr = seeker.Seek(start, io.SeekStart)
+		if sErr != nil {
+			return nil, sErr
+		}
+		return io.NopCloser(seeker), nil
+	}
+	if readerAt, ok := seeker.(io.ReaderAt); ok {
+		getBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(io.NewSectionReader(readerAt, start, end-start)), nil
+		}
+	}
+	var body = NewReplayableBody(end-start, getBody)
+	body.closer, _ = seeker.(io.Closer)
+	return body, nil
+}
+
+// Read 读取body，首次读取时打开
+func (b *ReplayableBody) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		var err error
+		b.reader, err = b.getBody()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return b.reader.Read(p)
+}
+
+// Close 关闭当前读取的body
+func (b *ReplayableBody) Close() error {
+	if b.reader == nil {
+		return nil
+	}
+	var err = b.reader.Close()
+	b.reader = nil
+	return err
+}
+
+// GetBody 返回一个新的body
+func (b *ReplayableBody) GetBody() (io.ReadCloser, error) {
+	return b.getBody()
+}
+
+// release 关闭底层数据源，只关闭一次
+func (b *ReplayableBody) release() {
+	if b.closer != nil {
+		_ = b.closer.Close()
+		b.closer = nil
+	}
+}
+
+// ContentLength body长度，小于0表示未知
+func (b *ReplayableBody) ContentLength() int64 {
+	return b.size
+}
+
+// apply 将body设置到http请求上
+func (b *ReplayableBody) apply(req *http.Request) error {
+	if b.size == 0 {
+		req.Body = http.NoBody
+		req.GetBody = func() (io.ReadCloser, error) { return http.NoBody, nil }
+		req.ContentLength = 0
+		return nil
+	}
+	// opened on the first read, so that an unsent request holds no resources such as pipe goroutines or files
+	req.Body, _ = b.lazyBody()
+	req.GetBody = b.lazyBody
+	req.ContentLength = b.size
+	return nil
+}
+
+// lazyBody 返回一个首次读取时才打开的新body
+func (b *ReplayableBody) lazyBody() (io.ReadCloser, error) {
+	return &ReplayableBody{getBody: b.getBody, size: b.size}, nil
+}
+
+// makeReplayable 尽可能将BodyParam转为可重复读取的body，无法转换时原样返回
+func makeReplayable(p *BodyParam) (io.Reader, error) {
+	if p.GetBody != nil {
+		var size = p.ContentLength
+		if size <= 0 {
+			size = -1
+		}
+		return NewReplayableBody(size, p.GetBody), nil
+	}
+	switch v := p.Value.(type) {
+	case nil:
+		return nil, nil
+	case *ReplayableBody:
+		return v, nil
+	case io.ReadSeeker:
+		return NewSeekerBody(v)
+	}
+	return p.Value, nil
+}
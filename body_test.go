@@ -0,0 +1,176 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestClient_RedirectReplayBody(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusTemporaryRedirect)
+			return
+		}
+		var body, _ = io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL))
+	var requests = map[string]IRequest{
+		`{"name":"json"}`: NewRequest("POST", "/old").SetJSONBody(map[string]string{"name": "json"}),
+		"seeker":          NewRequest("POST", "/old").SetBody("text/plain", strings.NewReader("seeker")),
+		"func": NewRequest("POST", "/old").SetBodyFunc("text/plain", 4, func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("func")), nil
+		}),
+	}
+	for expect, req := range requests {
+		var rsp, err = c.Do(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data []byte
+		data, err = rsp.Data()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expect {
+			t.Errorf("expect %s, got %s", expect, data)
+		}
+	}
+}
+
+func TestRequest_StreamMultipart(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			_, _ = io.Copy(io.Discard, r.Body)
+			return
+		}
+		if r.ContentLength <= 0 {
+			t.Errorf("expect known content length, got %d", r.ContentLength)
+		}
+		var f, _, err = r.FormFile("file")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer f.Close()
+		var data, _ = io.ReadAll(f)
+		_, _ = w.Write([]byte(r.FormValue("k") + ":" + string(data)))
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL))
+	var req = NewRequest("POST", "/").AddFormItem("k", "v").AddFileBytes("file", "a.txt", []byte("content"))
+	var rsp, err = c.Do(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	data, err = rsp.Data()
+	if err != nil || string(data) != "v:content" {
+		t.Fatalf("unexpected response: %q %v", data, err)
+	}
+
+	var writeErr = errors.New("write failed")
+	req = NewRequest("POST", "/broken").AddParam(&FileParam{
+		Name:     "file",
+		FileName: "b.txt",
+		FileWriterFunc: func(w io.Writer) error {
+			return writeErr
+		},
+	})
+	_, err = c.Do(context.Background(), req)
+	if !errors.Is(err, writeErr) {
+		t.Errorf("expect write error, got %v", err)
+	}
+}
+
+func TestSeekerBody_IndependentReaders(t *testing.T) {
+	var src = strings.NewReader("--payload")
+	_, _ = src.Seek(2, io.SeekStart)
+	var body, err = NewSeekerBody(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a previous attempt still being read by the transport does not move the offset of the next one
+	var first, _ = body.GetBody()
+	var head = make([]byte, 3)
+	_, _ = io.ReadFull(first, head)
+	var second, _ = body.GetBody()
+	var all, _ = io.ReadAll(second)
+	var rest, _ = io.ReadAll(first)
+	if string(all) != "payload" || string(head)+string(rest) != "payload" {
+		t.Errorf("unexpected bodies: %q %q%q", all, head, rest)
+	}
+}
+
+func TestClient_FileBodyClosed(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body, _ = io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	var path = filepath.Join(t.TempDir(), "body.txt")
+	if err := os.WriteFile(path, []byte("file"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var cases = map[string][]OptionFn{
+		"sent":  {WithBaseURL(srv.URL)},
+		"retry": {WithBaseURL(srv.URL), WithRetry(DefaultRetryPolicy())},
+		"aborted": {WithBaseURL(srv.URL), WithMiddleware(func(next Handler) Handler {
+			return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				return nil, errors.New("abort")
+			}
+		})},
+	}
+	for name, opts := range cases {
+		var f, err = os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rsp IResponse
+		rsp, err = New(opts...).Do(context.Background(), NewRequest("POST", "/").SetBody("text/plain", f))
+		if err == nil {
+			var data, _ = rsp.Data()
+			if string(data) != "file" {
+				t.Errorf("%s: expect file, got %q", name, data)
+			}
+		}
+		if err = f.Close(); !errors.Is(err, os.ErrClosed) {
+			t.Errorf("%s: expect file closed by Do, got %v", name, err)
+		}
+	}
+}
+
+func TestClient_UnsentMultipartNoLeak(t *testing.T) {
+	var abort = errors.New("abort")
+	var aborting = WithMiddleware(func(next Handler) Handler {
+		return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			return nil, abort
+		}
+	})
+	// compressed bodies are opened lazily as well
+	for _, c := range []*Client{
+		New(WithBaseURL("http://127.0.0.1:1"), aborting),
+		New(WithBaseURL("http://127.0.0.1:1"), aborting, WithRequestCompression("gzip", 0)),
+	} {
+		var before = runtime.NumGoroutine()
+		for i := 0; i < 50; i++ {
+			var req = NewRequest("POST", "/").AddFileBytes("file", "a.txt", []byte("data"))
+			var _, err = c.Do(context.Background(), req)
+			if !errors.Is(err, abort) {
+				t.Fatalf("expect abort, got %v", err)
+			}
+		}
+		if after := runtime.NumGoroutine(); after > before+5 {
+			t.Errorf("goroutines leaked: %d -> %d", before, after)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitState 熔断器状态
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// ErrCircuitOpen 熔断器打开，请求未发送
+var ErrCircuitOpen = errors.New("restgo: circuit breaker is open")
+
+// CircuitOpenError 熔断器打开时返回的错误，errors.Is(err, ErrCircuitOpen)为true
+type CircuitOpenError struct {
+	Key string
+}
+
+func (e *CircuitOpenError) Error() string {
+	return ErrCircuitOpen.Error() + ": " + e.Key
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitBreakerConfig 熔断器配置
+type CircuitBreakerConfig struct {
+	// FailureThreshold 连续失败多少次后打开，默认5
+	FailureThreshold int
+	// OpenTimeout 打开多久后进入半开状态，默认30s
+	OpenTimeout time.Duration
+	// HalfOpenRequests 半开状态允许的探测请求数，全部成功后关闭，默认1
+	HalfOpenRequests int
+	// FailureStatusCodes 视为失败的状态码，为空时5xx视为失败
+	FailureStatusCodes []int
+	// KeyFunc 熔断的维度，默认按host，可被WithCircuitBreakerKey覆盖
+	KeyFunc func(req *http.Request) string
+	// OnStateChange 状态变化回调，在锁外调用
+	OnStateChange func(key string, from, to CircuitState)
+}
+
+// CircuitBreaker 按host（或请求指定的名称）熔断
+type CircuitBreaker struct {
+	cfg      CircuitBreakerConfig
+	lock     sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state     CircuitState
+	failures  int
+	successes int
+	inflight  int
+	openedAt  time.Time
+}
+
+type stateChange struct {
+	key      string
+	from, to CircuitState
+}
+
+func NewCircuitBreaker(cfg CircuitBreakerConfig) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = 1
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = func(req *http.Request) string {
+			return req.URL.Host
+		}
+	}
+	return &CircuitBreaker{cfg: cfg, circuits: make(map[string]*circuit)}
+}
+
+// State 获取指定key的状态
+func (b *CircuitBreaker) State(key string) CircuitState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	var c, ok = b.circuits[key]
+	if !ok {
+		return CircuitClosed
+	}
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= b.cfg.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// Middleware 熔断中间件
+func (b *CircuitBreaker) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			var key = b.key(ctx, req)
+			if !b.allow(key) {
+				closeRequestBody(req)
+				return nil, &CircuitOpenError{Key: key}
+			}
+			// nolint: bodyclose
+			var rsp, err = next(ctx, req)
+			if err != nil && ctx.Err() == context.Canceled {
+				// canceled by caller, not a failure of the downstream
+				b.release(key)
+				return rsp, err
+			}
+			b.done(key, b.isFailure(rsp, err))
+			return rsp, err
+		}
+	}
+}
+
+func (b *CircuitBreaker) key(ctx context.Context, req *http.Request) string {
+	var state = getCallState(ctx)
+	if state.opt != nil && state.opt.circuitKey != "" {
+		return state.opt.circuitKey
+	}
+	return b.cfg.KeyFunc(req)
+}
+
+func (b *CircuitBreaker) isFailure(rsp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	if len(b.cfg.FailureStatusCodes) == 0 {
+		return rsp.StatusCode >= 500
+	}
+	for _, code := range b.cfg.FailureStatusCodes {
+		if code == rsp.StatusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *CircuitBreaker) allow(key string) bool {
+	var change *stateChange
+	defer func() { b.notify(change) }()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	var c, ok = b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	if c.state == CircuitOpen {
+		if time.Since(c.openedAt) < b.cfg.OpenTimeout {
+			return false
+		}
+		change = b.transit(key, c, CircuitHalfOpen)
+	}
+	if c.state == CircuitHalfOpen {
+		if c.inflight >= b.cfg.HalfOpenRequests-c.successes {
+			return false
+		}
+	}
+	c.inflight++
+	return true
+}
+
+func (b *CircuitBreaker) release(key string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if c, ok := b.circuits[key]; ok && c.inflight > 0 {
+		c.inflight--
+	}
+}
+
+func (b *CircuitBreaker) done(key string, failed bool) {
+	var change *stateChange
+	defer func() { b.notify(change) }()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	var c = b.circuits[key]
+	if c.inflight > 0 {
+		c.inflight--
+	}
+	switch c.state {
+	case CircuitClosed:
+		if !failed {
+			c.failures = 0
+			return
+		}
+		c.failures++
+		if c.failures >= b.cfg.FailureThreshold {
+			change = b.transit(key, c, CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if failed {
+			change = b.transit(key, c, CircuitOpen)
+			return
+		}
+		c.successes++
+		if c.successes >= b.cfg.HalfOpenRequests {
+			change = b.transit(key, c, CircuitClosed)
+		}
+	case CircuitOpen:
+		// request started before the circuit opened
+	}
+}
+
+// transit 切换状态，调用方持有锁
+func (b *CircuitBreaker) transit(key string, c *circuit, to CircuitState) *stateChange {
+	var from = c.state
+	c.state = to
+	c.failures = 0
+	c.successes = 0
+	if to == CircuitOpen {
+		c.openedAt = time.Now()
+	}
+	return &stateChange{key: key, from: from, to: to}
+}
+
+func (b *CircuitBreaker) notify(change *stateChange) {
+	if change == nil || b.cfg.OnStateChange == nil {
+		return
+	}
+	b.cfg.OnStateChange(change.key, change.from, change.to)
+}
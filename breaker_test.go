@@ -0,0 +1,66 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var healthy int32
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+	var changes []CircuitState
+	var breaker = NewCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      20 * time.Millisecond,
+		OnStateChange: func(key string, from, to CircuitState) {
+			changes = append(changes, to)
+		},
+	})
+	var c = New(WithBaseURL(srv.URL), WithCircuitBreaker(breaker))
+	var ctx = context.Background()
+	for i := 0; i < 2; i++ {
+		var rsp, err = c.Get(ctx, "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = rsp.ExplicitCloseBody()
+	}
+	var _, err = c.Get(ctx, "/")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expect circuit open, got %v", err)
+	}
+	// another key is not affected
+	var rsp IResponse
+	rsp, err = c.Do(ctx, NewRequest("GET", "/"), WithCircuitBreakerKey("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rsp.ExplicitCloseBody()
+
+	time.Sleep(30 * time.Millisecond)
+	atomic.StoreInt32(&healthy, 1)
+	rsp, err = c.Get(ctx, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rsp.ExplicitCloseBody()
+	var expect = []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if len(changes) != len(expect) {
+		t.Fatalf("unexpected state changes: %v", changes)
+	}
+	for i := range expect {
+		if changes[i] != expect[i] {
+			t.Errorf("unexpected state changes: %v", changes)
+		}
+	}
+}
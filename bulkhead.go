@@ -0,0 +1,190 @@
+package restgo
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ErrBulkheadFull 等待队列已满，请求未发送
+var ErrBulkheadFull = errors.New("restgo: bulkhead queue is full")
+
+// BulkheadConfig 并发限制配置
+type BulkheadConfig struct {
+	// MaxConcurrent 整个客户端的最大并发请求数，小于等于0表示不限制
+	MaxConcurrent int
+	// MaxPerHost 每个host的最大并发请求数，小于等于0表示不限制
+	MaxPerHost int
+	// MaxQueue 每个限制的最大排队数，超过时返回ErrBulkheadFull，小于等于0表示不限制
+	MaxQueue int
+}
+
+// Bulkhead 限制客户端及每个host的并发请求数，超出的请求排队等待
+// 请求从发送开始到响应body关闭为止占用一个并发数
+type Bulkhead struct {
+	cfg    BulkheadConfig
+	global *semaphore
+	lock   sync.Mutex
+	hosts  map[string]*semaphore
+}
+
+func NewBulkhead(cfg BulkheadConfig) *Bulkhead {
+	return &Bulkhead{
+		cfg:    cfg,
+		global: newSemaphore(cfg.MaxConcurrent),
+		hosts:  make(map[string]*semaphore),
+	}
+}
+
+// InFlight 客户端当前并发请求数
+func (b *Bulkhead) InFlight() int {
+	var inflight, _ = b.global.stats()
+	return inflight
+}
+
+// Queued 等待客户端并发数的请求数
+func (b *Bulkhead) Queued() int {
+	var _, queued = b.global.stats()
+	return queued
+}
+
+// HostStats 指定host当前的并发请求数和排队请求数
+func (b *Bulkhead) HostStats(host string) (inflight, queued int) {
+	b.lock.Lock()
+	var s, ok = b.hosts[host]
+	b.lock.Unlock()
+	if !ok {
+		return 0, 0
+	}
+	return s.stats()
+}
+
+// Acquire 获取host及客户端的并发数，返回释放函数
+func (b *Bulkhead) Acquire(ctx context.Context, host string) (func(), error) {
+	var hostSem = b.host(host)
+	var err = hostSem.acquire(ctx, b.cfg.MaxQueue)
+	if err != nil {
+		return nil, err
+	}
+	err = b.global.acquire(ctx, b.cfg.MaxQueue)
+	if err != nil {
+		hostSem.release()
+		return nil, err
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.global.release()
+			hostSem.release()
+		})
+	}, nil
+}
+
+// Middleware 并发限制中间件
+func (b *Bulkhead) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			var release, err = b.Acquire(ctx, req.URL.Host)
+			if err != nil {
+				closeRequestBody(req)
+				return nil, err
+			}
+			// nolint: bodyclose
+			var rsp, rErr = next(ctx, req)
+			if rErr != nil {
+				release()
+				return nil, rErr
+			}
+			rsp.Body = &releaseBody{ReadCloser: rsp.Body, release: release}
+			return rsp, nil
+		}
+	}
+}
+
+func (b *Bulkhead) host(host string) *semaphore {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	var s, ok = b.hosts[host]
+	if !ok {
+		s = newSemaphore(b.cfg.MaxPerHost)
+		b.hosts[host] = s
+	}
+	return s
+}
+
+// releaseBody 关闭body时释放并发数
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	var err = b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// semaphore 先进先出的信号量
+type semaphore struct {
+	lock     sync.Mutex
+	limit    int
+	inflight int
+	waiters  list.List
+}
+
+func newSemaphore(limit int) *semaphore {
+	return &semaphore{limit: limit}
+}
+
+func (s *semaphore) acquire(ctx context.Context, maxQueue int) error {
+	s.lock.Lock()
+	if s.limit <= 0 || (s.inflight < s.limit && s.waiters.Len() == 0) {
+		s.inflight++
+		s.lock.Unlock()
+		return nil
+	}
+	if maxQueue > 0 && s.waiters.Len() >= maxQueue {
+		s.lock.Unlock()
+		return ErrBulkheadFull
+	}
+	var ready = make(chan struct{})
+	var elem = s.waiters.PushBack(ready)
+	s.lock.Unlock()
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.lock.Lock()
+		select {
+		case <-ready:
+			// acquired while canceling, give it back
+			s.lock.Unlock()
+			s.release()
+		default:
+			s.waiters.Remove(elem)
+			s.lock.Unlock()
+		}
+		return ctx.Err()
+	}
+}
+
+// release 释放，有等待者时直接转交给最早的等待者
+func (s *semaphore) release() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if front := s.waiters.Front(); front != nil && s.limit > 0 {
+		s.waiters.Remove(front)
+		close(front.Value.(chan struct{}))
+		return
+	}
+	s.inflight--
+}
+
+func (s *semaphore) stats() (inflight, queued int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.inflight, s.waiters.Len()
+}
@@ -0,0 +1,51 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBulkhead(t *testing.T) {
+	var b = NewBulkhead(BulkheadConfig{MaxConcurrent: 2, MaxPerHost: 1, MaxQueue: 1})
+	var ctx = context.Background()
+	var release, err = b.Acquire(ctx, "a.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var acquired = make(chan func())
+	go func() {
+		var r, aErr = b.Acquire(ctx, "a.com")
+		if aErr != nil {
+			t.Error(aErr)
+		}
+		acquired <- r
+	}()
+	for {
+		if _, queued := b.HostStats("a.com"); queued == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err = b.Acquire(ctx, "a.com"); !errors.Is(err, ErrBulkheadFull) {
+		t.Errorf("expect queue full, got %v", err)
+	}
+	var timeoutCtx, cancel = context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	var releaseB func()
+	releaseB, err = b.Acquire(timeoutCtx, "b.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.InFlight() != 2 {
+		t.Errorf("unexpected in flight: %d", b.InFlight())
+	}
+	release()
+	var releaseQueued = <-acquired
+	releaseQueued()
+	releaseB()
+	if b.InFlight() != 0 || b.Queued() != 0 {
+		t.Errorf("unexpected stats: %d %d", b.InFlight(), b.Queued())
+	}
+}
@@ -0,0 +1,381 @@
+package restgo
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerCacheControl    = "Cache-Control"
+	headerETag            = "ETag"
+	headerLastModified    = "Last-Modified"
+	headerIfNoneMatch     = "If-None-Match"
+	headerIfModifiedSince = "If-Modified-Since"
+)
+
+// heuristicStatusCodes 无明确过期时间时可以按Last-Modified启发式缓存的状态码
+var heuristicStatusCodes = map[int]bool{
+	200: true, 203: true, 204: true, 300: true, 301: true,
+	308: true, 404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+// httpCache 私有HTTP缓存（RFC 9111），只缓存GET请求
+type httpCache struct {
+	store CacheStore
+}
+
+func newHTTPCache(store CacheStore) *httpCache {
+	return &httpCache{store: store}
+}
+
+func (c *httpCache) middleware(next Handler) Handler {
+	return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		var state = getCallState(ctx)
+		if state.opt != nil && state.opt.disableCache {
+			return next(ctx, req)
+		}
+		var key = cacheKey(req)
+		if req.Method != "GET" {
+			// nolint: bodyclose
+			var rsp, err = next(ctx, req)
+			if err == nil && req.Method != "HEAD" && rsp.StatusCode < 400 {
+				// unsafe methods invalidate the cached response
+				_ = c.store.Delete(key)
+			}
+			return rsp, err
+		}
+		var reqCC = parseCacheControl(req.Header)
+		if reqCC.has("no-store") {
+			return next(ctx, req)
+		}
+		var entry, _ = c.store.Get(key)
+		if entry != nil && !varyMatches(entry, req) {
+			entry = nil
+		}
+		var now = time.Now()
+		if entry != nil && c.usable(entry, reqCC, now) {
+			closeRequestBody(req)
+			state.fromCache = true
+			return entry.response(req, now), nil
+		}
+		if reqCC.has("only-if-cached") {
+			closeRequestBody(req)
+			return gatewayTimeout(req), nil
+		}
+		var sendReq = req
+		if entry != nil {
+			sendReq = conditionalRequest(req, entry)
+		}
+		var requestTime = time.Now()
+		// nolint: bodyclose
+		var rsp, err = next(ctx, sendReq)
+		if entry != nil && (err != nil || rsp.StatusCode >= 500) && staleIfError(entry, reqCC, time.Now()) {
+			if err == nil {
+				drainBody(rsp.Body)
+			}
+			state.fromCache = true
+			return entry.response(req, time.Now()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if rsp.StatusCode == http.StatusNotModified && entry != nil && sendReq != req {
+			drainBody(rsp.Body)
+			entry = entry.revalidated(rsp, requestTime, time.Now())
+			_ = c.store.Set(key, entry)
+			state.fromCache = true
+			return entry.response(req, time.Now()), nil
+		}
+		var authenticated = state.opt != nil && state.opt.auth != nil || req.Header.Get(headerAuthorization) != ""
+		if storable(req, rsp, authenticated) {
+			rsp.Body = &cachingBody{ReadCloser: rsp.Body, onEOF: func(data []byte) {
+				_ = c.store.Set(key, newCacheEntry(req, rsp, data, requestTime, time.Now()))
+			}}
+		}
+		return rsp, nil
+	}
+}
+
+// usable 缓存是否可以不经验证直接使用
+func (c *httpCache) usable(entry *CacheEntry, reqCC cacheControl, now time.Time) bool {
+	var rspCC = parseCacheControl(entry.Header)
+	if reqCC.has("no-cache") || rspCC.has("no-cache") || headerHasNoCache(entry) {
+		return false
+	}
+	var age = entry.age(now)
+	if maxAge, ok := reqCC.duration("max-age"); ok && age > maxAge {
+		return false
+	}
+	var fresh = entry.freshnessLifetime() - age
+	if minFresh, ok := reqCC.duration("min-fresh"); ok {
+		fresh -= minFresh
+	}
+	if fresh > 0 {
+		return true
+	}
+	if rspCC.has("must-revalidate") || !reqCC.has("max-stale") {
+		return false
+	}
+	var maxStale, ok = reqCC.duration("max-stale")
+	// max-stale without value accepts any staleness
+	return !ok || -fresh <= maxStale
+}
+
+func headerHasNoCache(entry *CacheEntry) bool {
+	return strings.Contains(strings.ToLower(entry.Header.Get("Pragma")), "no-cache") &&
+		entry.Header.Get(headerCacheControl) == ""
+}
+
+// staleIfError 出错时是否可以使用过期缓存
+func staleIfError(entry *CacheEntry, reqCC cacheControl, now time.Time) bool {
+	var rspCC = parseCacheControl(entry.Header)
+	if rspCC.has("must-revalidate") {
+		return false
+	}
+	var window, ok = reqCC.duration("stale-if-error")
+	if !ok {
+		window, ok = rspCC.duration("stale-if-error")
+	}
+	if !ok {
+		return false
+	}
+	var stale = entry.age(now) - entry.freshnessLifetime()
+	return stale <= window
+}
+
+func cacheKey(req *http.Request) string {
+	return "GET " + req.URL.String()
+}
+
+func varyMatches(entry *CacheEntry, req *http.Request) bool {
+	for _, field := range varyFields(entry.Header) {
+		if field == "*" {
+			return false
+		}
+		if strings.Join(entry.VaryHeader.Values(field), ",") != strings.Join(req.Header.Values(field), ",") {
+			return false
+		}
+	}
+	return true
+}
+
+func varyFields(header http.Header) []string {
+	var fields []string
+	for _, v := range header.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				fields = append(fields, http.CanonicalHeaderKey(f))
+			}
+		}
+	}
+	return fields
+}
+
+// conditionalRequest 构造验证请求，调用方已设置条件头时不覆盖
+func conditionalRequest(req *http.Request, entry *CacheEntry) *http.Request {
+	var etag, lastModified = entry.Header.Get(headerETag), entry.Header.Get(headerLastModified)
+	if (etag == "" && lastModified == "") ||
+		req.Header.Get(headerIfNoneMatch) != "" || req.Header.Get(headerIfModifiedSince) != "" {
+		return req
+	}
+	var cond = req.Clone(req.Context())
+	if etag != "" {
+		cond.Header.Set(headerIfNoneMatch, etag)
+	}
+	if lastModified != "" {
+		cond.Header.Set(headerIfModifiedSince, lastModified)
+	}
+	return cond
+}
+
+// storable 响应是否可以存储
+// 缓存在派生客户端间共享，认证请求的响应按共享缓存处理（RFC 9111 3.5），需显式允许才能存储
+func storable(req *http.Request, rsp *http.Response, authenticated bool) bool {
+	var reqCC, rspCC = parseCacheControl(req.Header), parseCacheControl(rsp.Header)
+	if reqCC.has("no-store") || rspCC.has("no-store") {
+		return false
+	}
+	if authenticated && !rspCC.has("public") && !rspCC.has("s-maxage") && !rspCC.has("must-revalidate") {
+		return false
+	}
+	for _, field := range varyFields(rsp.Header) {
+		if field == "*" {
+			return false
+		}
+	}
+	if rspCC.has("max-age") || rsp.Header.Get("Expires") != "" {
+		return rsp.StatusCode < 500 || rsp.StatusCode == http.StatusNotImplemented
+	}
+	if !heuristicStatusCodes[rsp.StatusCode] {
+		return false
+	}
+	return rspCC.has("no-cache") || rsp.Header.Get(headerETag) != "" || rsp.Header.Get(headerLastModified) != ""
+}
+
+func newCacheEntry(req *http.Request, rsp *http.Response, body []byte, requestTime, responseTime time.Time) *CacheEntry {
+	var entry = &CacheEntry{
+		StatusCode:   rsp.StatusCode,
+		Status:       rsp.Status,
+		Header:       rsp.Header.Clone(),
+		Body:         body,
+		RequestTime:  requestTime,
+		ResponseTime: responseTime,
+		VaryHeader:   http.Header{},
+	}
+	for _, field := range varyFields(rsp.Header) {
+		if values := req.Header.Values(field); len(values) != 0 {
+			entry.VaryHeader[field] = append([]string(nil), values...)
+		}
+	}
+	return entry
+}
+
+// revalidated 收到304后更新缓存的header
+func (e *CacheEntry) revalidated(rsp *http.Response, requestTime, responseTime time.Time) *CacheEntry {
+	var n = *e
+	n.Header = e.Header.Clone()
+	for k, v := range rsp.Header {
+		if k == "Content-Length" {
+			continue
+		}
+		n.Header[k] = append([]string(nil), v...)
+	}
+	n.RequestTime = requestTime
+	n.ResponseTime = responseTime
+	return &n
+}
+
+// age 当前缓存的年龄（RFC 9111 4.2.3）
+func (e *CacheEntry) age(now time.Time) time.Duration {
+	var date = e.date()
+	var apparentAge = e.ResponseTime.Sub(date)
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+	var ageValue time.Duration
+	if seconds, err := strconv.ParseInt(e.Header.Get("Age"), 10, 64); err == nil && seconds > 0 {
+		ageValue = time.Duration(seconds) * time.Second
+	}
+	var correctedAge = ageValue + e.ResponseTime.Sub(e.RequestTime)
+	if apparentAge > correctedAge {
+		correctedAge = apparentAge
+	}
+	return correctedAge + now.Sub(e.ResponseTime)
+}
+
+// freshnessLifetime 缓存的有效期（RFC 9111 4.2.1、4.2.2）
+func (e *CacheEntry) freshnessLifetime() time.Duration {
+	var cc = parseCacheControl(e.Header)
+	if maxAge, ok := cc.duration("max-age"); ok {
+		return maxAge
+	}
+	if expires := e.Header.Get("Expires"); expires != "" {
+		var t, err = http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(e.date())
+	}
+	if lastModified, err := http.ParseTime(e.Header.Get(headerLastModified)); err == nil && heuristicStatusCodes[e.StatusCode] {
+		// heuristic freshness, 10% of the time since last modified
+		var d = e.date().Sub(lastModified) / 10
+		if d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func (e *CacheEntry) date() time.Time {
+	var date, err = http.ParseTime(e.Header.Get("Date"))
+	if err != nil {
+		return e.ResponseTime
+	}
+	return date
+}
+
+func (e *CacheEntry) response(req *http.Request, now time.Time) *http.Response {
+	var header = e.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(e.age(now)/time.Second), 10))
+	return &http.Response{
+		Status:        e.Status,
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+func gatewayTimeout(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:     "504 Gateway Timeout",
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
+
+// cachingBody 读取到EOF时回调完整的body，未读完关闭时不回调
+type cachingBody struct {
+	io.ReadCloser
+	buf   bytes.Buffer
+	onEOF func(data []byte)
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	var n, err = b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF && b.onEOF != nil {
+		b.onEOF(b.buf.Bytes())
+		b.onEOF = nil
+	}
+	return n, err
+}
+
+// cacheControl Cache-Control指令，key为小写指令名
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	var cc = cacheControl{}
+	for _, v := range header.Values(headerCacheControl) {
+		for _, directive := range strings.Split(v, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			var name, value, _ = strings.Cut(directive, "=")
+			cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(name string) bool {
+	var _, ok = cc[name]
+	return ok
+}
+
+func (cc cacheControl) duration(name string) (time.Duration, bool) {
+	var v, ok = cc[name]
+	if !ok {
+		return 0, false
+	}
+	var seconds, err = strconv.ParseInt(v, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
@@ -0,0 +1,166 @@
+package restgo
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheEntry 缓存的响应，存储后不应被修改
+type CacheEntry struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+	// RequestTime 发送请求的时间
+	RequestTime time.Time
+	// ResponseTime 收到响应的时间
+	ResponseTime time.Time
+	// VaryHeader 响应Vary头指定的请求header的值
+	VaryHeader http.Header
+}
+
+// CacheStore 缓存存储
+type CacheStore interface {
+	// Get 获取缓存，不存在时返回nil, nil
+	Get(key string) (*CacheEntry, error)
+	Set(key string, entry *CacheEntry) error
+	Delete(key string) error
+}
+
+// MemoryCache 基于LRU的内存缓存
+type MemoryCache struct {
+	lock       sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache maxEntries为最大缓存条数，小于等于0表示不限制
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (c *MemoryCache) Get(key string) (*CacheEntry, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var elem, ok = c.items[key]
+	if !ok {
+		return nil, nil
+	}
+	c.ll.MoveToFront(elem)
+	return elem.Value.(*memoryCacheItem).entry, nil
+}
+
+func (c *MemoryCache) Set(key string, entry *CacheEntry) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*memoryCacheItem).entry = entry
+		c.ll.MoveToFront(elem)
+		return nil
+	}
+	c.items[key] = c.ll.PushFront(&memoryCacheItem{key: key, entry: entry})
+	if c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		var oldest = c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*memoryCacheItem).key)
+	}
+	return nil
+}
+
+func (c *MemoryCache) Delete(key string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.ll.Remove(elem)
+		delete(c.items, key)
+	}
+	return nil
+}
+
+// Len 缓存条数
+func (c *MemoryCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.ll.Len()
+}
+
+// DiskCache 磁盘缓存，每条缓存一个JSON文件
+type DiskCache struct {
+	dir string
+}
+
+func NewDiskCache(dir string) (*DiskCache, error) {
+	var err = os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+func (c *DiskCache) Get(key string) (*CacheEntry, error) {
+	var data, err = os.ReadFile(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry = &CacheEntry{}
+	err = json.Unmarshal(data, entry)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (c *DiskCache) Set(key string, entry *CacheEntry) error {
+	var data, err = json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	var f *os.File
+	f, err = os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), c.path(key))
+}
+
+func (c *DiskCache) Delete(key string) error {
+	var err = os.Remove(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (c *DiskCache) path(key string) string {
+	var sum = sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
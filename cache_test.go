@@ -0,0 +1,95 @@
+package restgo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_Cache(t *testing.T) {
+	var count, broken int32
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		if atomic.LoadInt32(&broken) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set(headerCacheControl, "max-age=60")
+		case "/etag":
+			w.Header().Set(headerCacheControl, "no-cache, stale-if-error=60")
+			w.Header().Set(headerETag, `"v1"`)
+			if r.Header.Get(headerIfNoneMatch) == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+	var dir = t.TempDir()
+	var disk, err = NewDiskCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, store := range []CacheStore{NewMemoryCache(10), disk} {
+		atomic.StoreInt32(&count, 0)
+		atomic.StoreInt32(&broken, 0)
+		var c = New(WithBaseURL(srv.URL), WithCache(store))
+		var get = func(resource string, fromCache bool) {
+			var rsp, gErr = c.Get(context.Background(), resource)
+			if gErr != nil {
+				t.Fatal(gErr)
+			}
+			var data, dErr = rsp.Data()
+			if dErr != nil || string(data) != resource || rsp.FromCache() != fromCache {
+				t.Errorf("unexpected response of %s: %q %v %v", resource, data, rsp.FromCache(), dErr)
+			}
+		}
+		get("/fresh", false)
+		get("/fresh", true)
+		get("/etag", false)
+		get("/etag", true)
+		if n := atomic.LoadInt32(&count); n != 3 {
+			t.Errorf("expect 3 requests, got %d", n)
+		}
+		atomic.StoreInt32(&broken, 1)
+		get("/etag", true)
+	}
+}
+
+func TestClient_CacheCredentials(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/public" {
+			w.Header().Set(headerCacheControl, "public, max-age=60")
+		} else {
+			w.Header().Set(headerCacheControl, "max-age=60")
+		}
+		_, _ = w.Write([]byte(r.Header.Get(headerAuthorization)))
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithCache(NewMemoryCache(10)))
+	var a = c.With(WithAuthenticator(BearerToken("A")))
+	var b = c.With(WithAuthenticator(BearerToken("B")))
+	var get = func(client *Client, resource string) (string, bool) {
+		var rsp, err = client.Get(context.Background(), resource)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data, _ = rsp.Data()
+		return string(data), rsp.FromCache()
+	}
+	for _, resource := range []string{"/private", "/public"} {
+		get(a, resource)
+	}
+	if data, fromCache := get(b, "/private"); data != "Bearer B" || fromCache {
+		t.Errorf("expect own response, got %q from cache %v", data, fromCache)
+	}
+	// explicitly public responses are shared
+	if data, fromCache := get(b, "/public"); data != "Bearer A" || !fromCache {
+		t.Errorf("expect shared public response, got %q from cache %v", data, fromCache)
+	}
+}
@@ -17,8 +17,14 @@ const (
 type Client struct {
 	baseURL      *url.URL
 	globalHeader http.Header
-	beforeHooks  []BeforeHookFunc
-	afterHooks   []AfterHookFunc
+	non2xxError  bool
+	retry        RetryPolicy
+	retryHooks   []RetryHookFunc
+	handler      Handler
+	// attempt handler for every attempt, including retries
+	attempt Handler
+	// opt options used to create this client, used by With
+	opt *option
 
 	client *http.Client
 }
@@ -28,27 +34,116 @@ func New(optFns ...OptionFn) *Client {
 	for _, fn := range optFns {
 		fn(o)
 	}
-	if o.transport == nil {
-		o.transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	return newClient(o)
+}
+
+// With 派生子客户端，子客户端继承当前客户端的配置并与其共享transport（连接池）和cookie jar
+// optFns在继承的配置之上生效：WithBasePath追加路径，WithHeader追加header，钩子和中间件追加在当前客户端之后
+func (c *Client) With(optFns ...OptionFn) *Client {
+	var o = c.opt.clone()
+	for _, fn := range optFns {
+		fn(o)
 	}
-	return &Client{
+	return newClient(o)
+}
+
+// Clone 复制客户端，与当前客户端共享transport和cookie jar
+func (c *Client) Clone() *Client {
+	return c.With()
+}
+
+func newClient(o *option) *Client {
+	if o.built == nil {
+		o.built = buildTransport(o.transport, o.transportOpts)
+	}
+	var c = &Client{
+		opt:          o.clone(),
 		baseURL:      o.baseURL,
 		globalHeader: o.globalHeader,
-		beforeHooks:  o.beforeHooks,
-		afterHooks:   o.afterHooks,
+		non2xxError:  o.non2xxError,
+		retry:        o.retry,
+		retryHooks:   o.retryHooks,
 		client: &http.Client{
 			Jar:           o.jar,
-			Transport:     o.transport,
+			Transport:     o.built,
 			Timeout:       o.timeout,
 			CheckRedirect: o.checkRedirect,
 		},
 	}
+	// hooks run in Do, before hooks before the request is built, after hooks on the final response
+	var middlewares = make([]Middleware, 0, len(o.middlewares)+len(o.callBuiltins))
+	middlewares = append(middlewares, o.middlewares...)
+	middlewares = append(middlewares, o.callBuiltins...)
+	c.handler = Chain(middlewares...)(c.send)
+	// built-in middlewares run for every attempt
+	c.attempt = Chain(o.builtins...)(c.roundTrip)
+	return c
 }
 
-func (c *Client) Do(ctx context.Context, req IRequest) (IResponse, error) {
-	// run before hooks
-	c.runBeforeHooks(req)
-	var rURL, err = req.MakeURL(CloneURL(c.baseURL))
+// Do 发送请求，opts在IRequest.WithOptions之后生效
+func (c *Client) Do(ctx context.Context, req IRequest, opts ...RequestOption) (IResponse, error) {
+	var state = &callState{req: req, opt: c.newRequestOption(req, opts)}
+	ctx = context.WithValue(ctx, callStateKey{}, state)
+	defer state.releaseBody()
+	if !state.opt.disableHooks {
+		for _, hook := range c.opt.beforeHooks {
+			hook(req)
+		}
+	}
+	var request, err = c.buildRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// authenticators may add credentials to the URL, errors report the URL before that
+	var requestURL = request.URL.Redacted()
+	var response *http.Response
+	// nolint: bodyclose
+	response, err = c.handler(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	// response body is closed by caller
+	// actually, it's automatically closed by Data access
+	var rsp = &Response{
+		rsp:       response,
+		attempts:  state.attempts,
+		fromCache: state.fromCache,
+		url:       requestURL,
+		rateLimit: ParseRateLimit(response.Header),
+	}
+	if state.opt.verifier != nil {
+		err = verifyResponse(ctx, state.opt.verifier, rsp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if state.opt.transformer != nil {
+		err = transformResponse(rsp, state.opt.transformer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !state.opt.disableHooks {
+		for _, hook := range c.opt.afterHooks {
+			hook(req, rsp)
+		}
+	}
+	if state.opt.non2xxError && !isSuccessStatus(rsp.StatusCode()) {
+		return nil, NewHTTPError(rsp)
+	}
+	return rsp, nil
+}
+
+// buildRequest 由IRequest构造http请求
+func (c *Client) buildRequest(ctx context.Context, req IRequest) (*http.Request, error) {
+	var state = c.getCallState(ctx)
+	var opt = state.opt
+	var err = req.Validate()
+	if err != nil {
+		return nil, err
+	}
+	var rURL string
+	rURL, err = req.MakeURL(CloneURL(opt.baseURL))
 	if err != nil {
 		return nil, err
 	}
@@ -57,12 +152,168 @@ func (c *Client) Do(ctx context.Context, req IRequest) (IResponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	if replayable, ok := body.(*ReplayableBody); ok {
+		state.body = replayable
+	} else if body != nil && opt.retry != nil {
+		// one-shot body is buffered in memory so that it can be replayed by retry
+		var data []byte
+		data, err = io.ReadAll(body)
+		if closer, ok := body.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		if err != nil {
+			return nil, err
+		}
+		body = NewBytesBody(data)
+	}
 	var request *http.Request
-	request, err = http.NewRequestWithContext(ctx, req.GetMethod(), rURL, body)
+	request, err = c.newHTTPRequest(ctx, req, opt.globalHeader, rURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if opt.transformer != nil && request.Body != nil && request.Body != http.NoBody {
+		err = transformRequest(request, opt.transformer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.opt.compression != "" {
+		err = compressRequest(request, c.opt.compression, c.opt.compressThreshold)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return request, nil
+}
+
+// send 中间件链末端，配置了重试策略时按策略重试，每次请求都经过内置中间件
+func (c *Client) send(ctx context.Context, request *http.Request) (*http.Response, error) {
+	var state = c.getCallState(ctx)
+	var policy = state.opt.retry
+	if policy == nil {
+		state.attempts = 1
+		return c.attempt(ctx, request)
+	}
+	for attempt := 1; ; attempt++ {
+		state.attempts = attempt
+		var attemptCtx, cancel = ctx, context.CancelFunc(func() {})
+		if timeout := policy.AttemptTimeout(); timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		// body is re-created for every attempt
+		var attemptReq = request.Clone(attemptCtx)
+		if attempt > 1 && request.GetBody != nil {
+			var err error
+			attemptReq.Body, err = request.GetBody()
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+		}
+		// nolint: bodyclose
+		var response, err = c.attempt(attemptCtx, attemptReq)
+		var wait, retry = policy.Backoff(attempt, attemptReq, response, err)
+		if !retry || ctx.Err() != nil {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			response.Body = &cancelBody{ReadCloser: response.Body, cancel: cancel}
+			return response, nil
+		}
+		if !state.opt.disableHooks {
+			c.runRetryHooks(state.req, request, attempt, response, err)
+		}
+		if response != nil {
+			drainBody(response.Body)
+		}
+		cancel()
+		if err = sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// roundTrip 内置中间件链末端，发送单次请求
+func (c *Client) roundTrip(ctx context.Context, request *http.Request) (*http.Response, error) {
+	var opt = c.getCallState(ctx).opt
+	// nolint: bodyclose
+	var response, err = c.authDo(ctx, opt, request)
+	if err == nil && response.StatusCode == http.StatusUnauthorized && opt.auth != nil {
+		response, err = c.reauthenticate(ctx, opt, request, response)
+	}
+	if err != nil || c.opt.disableDecompression {
+		return response, err
+	}
+	decodeResponse(response)
+	return response, nil
+}
+
+// authDo 认证、签名后发送请求
+func (c *Client) authDo(ctx context.Context, opt *requestOption, request *http.Request) (*http.Response, error) {
+	if opt.auth != nil {
+		var err = opt.auth.Authenticate(ctx, request)
+		if err != nil {
+			closeRequestBody(request)
+			return nil, err
+		}
+	}
+	if opt.signer != nil {
+		var err = signRequest(ctx, opt.signer, request)
+		if err != nil {
+			closeRequestBody(request)
+			return nil, err
+		}
+	}
+	return c.httpClient(opt).Do(request)
+}
+
+// reauthenticate 认证器实现UnauthorizedHandler时，收到401后重新认证并重发一次请求
+func (c *Client) reauthenticate(ctx context.Context, opt *requestOption, request *http.Request, response *http.Response) (*http.Response, error) {
+	var handler, ok = opt.auth.(UnauthorizedHandler)
+	if !ok {
+		return response, nil
+	}
+	var replayable = request.Body == nil || request.Body == http.NoBody || request.GetBody != nil
+	if !replayable || !handler.HandleUnauthorized(ctx, request, response) {
+		return response, nil
+	}
+	var replay = request.Clone(ctx)
+	if request.GetBody != nil {
+		var err error
+		replay.Body, err = request.GetBody()
+		if err != nil {
+			return response, nil
+		}
+	}
+	drainBody(response.Body)
+	return c.authDo(ctx, opt, replay)
+}
+
+// closeRequestBody 请求未发送时关闭body，释放流式body的资源
+func closeRequestBody(request *http.Request) {
+	if request.Body != nil {
+		_ = request.Body.Close()
+	}
+}
+
+// newHTTPRequest 构造http请求，可重复读取的body会设置GetBody
+func (c *Client) newHTTPRequest(ctx context.Context, req IRequest, globalHeader http.Header, rURL string, body io.Reader) (*http.Request, error) {
+	var replayable, ok = body.(*ReplayableBody)
+	if ok {
+		body = nil
+	}
+	var request, err = http.NewRequestWithContext(ctx, req.GetMethod(), rURL, body)
 	if err != nil {
 		return nil, err
 	}
-	for k, vList := range c.globalHeader {
+	if ok {
+		err = replayable.apply(request)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for k, vList := range globalHeader {
 		for _, v := range vList {
 			request.Header.Set(k, v)
 		}
@@ -72,18 +323,7 @@ func (c *Client) Do(ctx context.Context, req IRequest) (IResponse, error) {
 	if ua == "" {
 		request.Header.Set(headerUserAgent, defaultUA)
 	}
-	var response *http.Response
-	// nolint: bodyclose
-	response, err = c.client.Do(request)
-	if err != nil {
-		return nil, err
-	}
-	// response body is closed by caller
-	// actually, it's automatically closed by Data access
-	var rsp = NewResponse(response)
-	// run after hooks
-	c.runAfterHooks(req, rsp)
-	return rsp, nil
+	return request, nil
 }
 
 func (c *Client) Execute(ctx context.Context, method, resource string, params ...IParam) (IResponse, error) {
@@ -104,14 +344,89 @@ func (c *Client) Put(ctx context.Context, resource string, params ...IParam) (IR
 	return c.Execute(ctx, "PUT", resource, params...)
 }
 
-func (c *Client) runBeforeHooks(req IRequest) {
-	for _, hook := range c.beforeHooks {
-		hook(req)
+func (c *Client) Delete(ctx context.Context, resource string, params ...IParam) (IResponse, error) {
+	return c.Execute(ctx, "DELETE", resource, params...)
+}
+
+func (c *Client) Patch(ctx context.Context, resource string, params ...IParam) (IResponse, error) {
+	return c.Execute(ctx, "PATCH", resource, params...)
+}
+
+// Head HEAD请求，响应没有body，返回前已关闭，无需调用ExplicitCloseBody
+func (c *Client) Head(ctx context.Context, resource string, params ...IParam) (IResponse, error) {
+	var rsp, err = c.Execute(ctx, "HEAD", resource, params...)
+	if err != nil {
+		return nil, err
+	}
+	_, err = rsp.Data()
+	if err != nil {
+		return nil, err
+	}
+	return rsp, nil
+}
+
+func (c *Client) Options(ctx context.Context, resource string, params ...IParam) (IResponse, error) {
+	return c.Execute(ctx, "OPTIONS", resource, params...)
+}
+
+// newRequestOption 依次应用Client配置、IRequest.WithOptions以及Do传入的配置
+func (c *Client) newRequestOption(req IRequest, opts []RequestOption) *requestOption {
+	var opt = &requestOption{
+		baseURL:       c.baseURL,
+		globalHeader:  c.globalHeader,
+		timeout:       c.client.Timeout,
+		checkRedirect: c.client.CheckRedirect,
+		retry:         c.retry,
+		non2xxError:   c.non2xxError,
+		auth:          c.opt.auth,
+		authID:        c.opt.authID,
+		signer:        c.opt.signer,
+		verifier:      c.opt.verifier,
+		transformer:   c.opt.transformer,
+	}
+	for _, fn := range req.GetOptions() {
+		fn(opt)
+	}
+	for _, fn := range opts {
+		fn(opt)
+	}
+	return opt
+}
+
+func (c *Client) getCallState(ctx context.Context) *callState {
+	var state = getCallState(ctx)
+	if state.opt == nil {
+		// handler invoked outside Client.Do
+		state.opt = c.newRequestOption(NewRequest("", ""), nil)
+	}
+	return state
+}
+
+// httpClient 单次请求覆盖了超时或重定向策略时使用client的副本
+func (c *Client) httpClient(opt *requestOption) *http.Client {
+	if !opt.customClient {
+		return c.client
 	}
+	var client = *c.client
+	client.Timeout = opt.timeout
+	client.CheckRedirect = opt.checkRedirect
+	return &client
 }
 
-func (c *Client) runAfterHooks(req IRequest, rsp IResponse) {
-	for _, hook := range c.afterHooks {
-		hook(req, rsp)
+func (c *Client) runRetryHooks(req IRequest, request *http.Request, attempt int, response *http.Response, err error) {
+	if len(c.retryHooks) == 0 {
+		return
+	}
+	var rsp IResponse
+	if response != nil {
+		rsp = &Response{
+			rsp:       response,
+			attempts:  attempt,
+			url:       request.URL.Redacted(),
+			rateLimit: ParseRateLimit(response.Header),
+		}
+	}
+	for _, hook := range c.retryHooks {
+		hook(req, attempt, rsp, err)
 	}
 }
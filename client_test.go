@@ -0,0 +1,88 @@
+package restgo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		_, _ = w.Write([]byte(r.Method))
+	}))
+}
+
+func TestClient_Methods(t *testing.T) {
+	var srv = newEchoServer()
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL))
+	var ctx = context.Background()
+	var calls = map[string]func(ctx context.Context, resource string, params ...IParam) (IResponse, error){
+		"GET":     c.Get,
+		"POST":    c.Post,
+		"PUT":     c.Put,
+		"DELETE":  c.Delete,
+		"PATCH":   c.Patch,
+		"OPTIONS": c.Options,
+	}
+	for method, call := range calls {
+		var rsp, err = call(ctx, "/")
+		if err != nil {
+			t.Fatal(method, err)
+		}
+		var data []byte
+		data, err = rsp.Data()
+		if err != nil {
+			t.Fatal(method, err)
+		}
+		if string(data) != method {
+			t.Errorf("expect %s, got %s", method, data)
+		}
+	}
+}
+
+func TestClient_Head(t *testing.T) {
+	var srv = newEchoServer()
+	defer srv.Close()
+	var rsp, err = Head(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp.GetResponse().Header.Get("X-Method") != "HEAD" {
+		t.Errorf("unexpected method header: %s", rsp.GetResponse().Header.Get("X-Method"))
+	}
+	var data []byte
+	data, err = rsp.Data()
+	if err != nil || len(data) != 0 {
+		t.Errorf("unexpected head body: %q %v", data, err)
+	}
+}
+
+func TestClient_With(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path + "," + r.Header.Get("X-Tenant") + "," + r.Header.Get("X-App")))
+	}))
+	defer srv.Close()
+	var parent = New(WithBaseURL(srv.URL+"/api"), WithHeader("X-App", "app"))
+	var child = parent.With(WithBasePath("v1", "users"), WithHeader("X-Tenant", "t1"))
+	if child.client.Transport != parent.client.Transport {
+		t.Error("expect shared transport")
+	}
+	var expects = map[*Client]string{
+		parent: "/api/list,,app",
+		child:  "/api/v1/users/list,t1,app",
+	}
+	for c, expect := range expects {
+		var rsp, err = c.Get(context.Background(), "list")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data []byte
+		data, err = rsp.Data()
+		if err != nil || string(data) != expect {
+			t.Errorf("expect %s, got %q %v", expect, data, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package restgo
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// coalescer 合并相同的进行中的GET/HEAD请求，只发送一次
+// 响应body会被完整读入内存，每个调用方得到独立的响应
+type coalescer struct {
+	headers []string
+	lock    sync.Mutex
+	calls   map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done chan struct{}
+	// waiters callers waiting for the call, the call is canceled when all of them have left
+	waiters int
+	cancel  context.CancelFunc
+	// state call state of the shared call, attempts and fromCache are copied to every caller
+	state *callState
+	rsp   *http.Response
+	data  []byte
+	err   error
+}
+
+func newCoalescer(headers []string) *coalescer {
+	return &coalescer{headers: headers, calls: make(map[string]*coalescedCall)}
+}
+
+func (c *coalescer) middleware(next Handler) Handler {
+	return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		var state = getCallState(ctx)
+		if (state.opt != nil && state.opt.disableCoalescing) ||
+			(req.Method != "GET" && req.Method != "HEAD") ||
+			(req.Body != nil && req.Body != http.NoBody) {
+			return next(ctx, req)
+		}
+		var key = c.key(req, state.opt)
+		c.lock.Lock()
+		var call, ok = c.calls[key]
+		if ok {
+			call.waiters++
+		} else {
+			// the shared call is not bound to the cancellation of the caller who starts it
+			var shared = *state
+			var sharedCtx, cancel = context.WithCancel(context.WithValue(withoutCancel(ctx), callStateKey{}, &shared))
+			call = &coalescedCall{done: make(chan struct{}), waiters: 1, cancel: cancel, state: &shared}
+			c.calls[key] = call
+			go c.do(sharedCtx, req.WithContext(sharedCtx), next, key, call)
+		}
+		c.lock.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			c.leave(key, call)
+			return nil, ctx.Err()
+		}
+		state.attempts = call.state.attempts
+		state.fromCache = call.state.fromCache
+		if call.err != nil {
+			return nil, call.err
+		}
+		return call.response(req), nil
+	}
+}
+
+// leave 调用方放弃等待，所有调用方都离开时取消请求，之后的调用方发起新的请求
+func (c *coalescer) leave(key string, call *coalescedCall) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	call.waiters--
+	if call.waiters == 0 {
+		c.remove(key, call)
+		call.cancel()
+	}
+}
+
+func (c *coalescer) remove(key string, call *coalescedCall) {
+	if c.calls[key] == call {
+		delete(c.calls, key)
+	}
+}
+
+func (c *coalescer) do(ctx context.Context, req *http.Request, next Handler, key string, call *coalescedCall) {
+	defer func() {
+		c.lock.Lock()
+		c.remove(key, call)
+		c.lock.Unlock()
+		call.cancel()
+		close(call.done)
+	}()
+	// nolint: bodyclose
+	call.rsp, call.err = next(ctx, req)
+	if call.err != nil {
+		return
+	}
+	defer call.rsp.Body.Close()
+	call.data, call.err = io.ReadAll(call.rsp.Body)
+}
+
+// key method + URL + 凭证（认证器及Authorization header）+ 指定的header
+func (c *coalescer) key(req *http.Request, opt *requestOption) string {
+	var sb strings.Builder
+	sb.WriteString(req.Method)
+	sb.WriteByte(' ')
+	sb.WriteString(req.URL.String())
+	if opt != nil && opt.auth != nil {
+		sb.WriteString("\nauth:")
+		sb.WriteString(strconv.FormatUint(opt.authID, 10))
+	}
+	sb.WriteString("\n" + headerAuthorization + ":")
+	sb.WriteString(req.Header.Get(headerAuthorization))
+	for _, name := range c.headers {
+		sb.WriteByte('\n')
+		sb.WriteString(name)
+		sb.WriteByte(':')
+		sb.WriteString(strings.Join(req.Header.Values(name), ","))
+	}
+	return sb.String()
+}
+
+// response 复制一个独立的响应
+func (call *coalescedCall) response(req *http.Request) *http.Response {
+	var rsp = *call.rsp
+	rsp.Header = call.rsp.Header.Clone()
+	rsp.Trailer = call.rsp.Trailer.Clone()
+	rsp.Body = io.NopCloser(bytes.NewReader(call.data))
+	rsp.ContentLength = int64(len(call.data))
+	rsp.Request = req
+	return &rsp
+}
+
+// detachedContext 保留父context的值，但不随父context取消
+type detachedContext struct {
+	parent context.Context
+}
+
+// withoutCancel 等同于go 1.21的context.WithoutCancel
+func withoutCancel(parent context.Context) context.Context {
+	return detachedContext{parent: parent}
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,175 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Coalescing(t *testing.T) {
+	var count int32
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte("shared"))
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithCoalescing("Authorization"))
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var rsp, err = c.Get(context.Background(), "/")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			var data []byte
+			data, err = rsp.Data()
+			if err != nil || string(data) != "shared" || rsp.Attempts() != 1 || rsp.FromCache() {
+				t.Errorf("unexpected response: %q %v %d %v", data, err, rsp.Attempts(), rsp.FromCache())
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&count); n != 1 {
+		t.Errorf("expect 1 request, got %d", n)
+	}
+}
+
+func TestClient_CoalescingCacheState(t *testing.T) {
+	var count int32
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			time.Sleep(50 * time.Millisecond)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("cached"))
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithCoalescing(), WithCache(NewMemoryCache(10)))
+	var rsp, err = c.Get(context.Background(), "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = rsp.Data()
+
+	// every caller of the shared revalidation reports its state
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var rsp, err = c.Get(context.Background(), "/")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			var data []byte
+			data, err = rsp.Data()
+			if err != nil || string(data) != "cached" || rsp.Attempts() != 1 || !rsp.FromCache() {
+				t.Errorf("unexpected response: %q %v %d %v", data, err, rsp.Attempts(), rsp.FromCache())
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&count); n != 2 {
+		t.Errorf("expect 2 requests, got %d", n)
+	}
+}
+
+func TestClient_CoalescingLeaderCanceled(t *testing.T) {
+	var count int32
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte("shared"))
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithCoalescing())
+	var leaderCtx, cancel = context.WithCancel(context.Background())
+	var leaderErr = make(chan error, 1)
+	go func() {
+		var _, err = c.Get(leaderCtx, "/")
+		leaderErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var rsp, err = c.Get(context.Background(), "/")
+			if err != nil {
+				t.Errorf("follower failed: %v", err)
+				return
+			}
+			var data, _ = rsp.Data()
+			if string(data) != "shared" {
+				t.Errorf("unexpected response %q", data)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expect leader canceled, got %v", err)
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&count); n != 1 {
+		t.Errorf("expect 1 request, got %d", n)
+	}
+}
+
+func TestClient_CoalescingCredentials(t *testing.T) {
+	var count int32
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte(r.Header.Get(headerAuthorization)))
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithCoalescing())
+	var clients = map[string]*Client{
+		"Bearer a": c.With(WithAuthenticator(BearerToken("a"))),
+		"Bearer b": c.With(WithAuthenticator(BearerToken("b"))),
+		"Bearer c": c,
+	}
+	var wg sync.WaitGroup
+	for expect, client := range clients {
+		var opts []RequestOption
+		if expect == "Bearer c" {
+			opts = append(opts, WithRequestAuthenticator(BearerToken("c")))
+		}
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func(expect string, client *Client) {
+				defer wg.Done()
+				var rsp, err = client.Do(context.Background(), NewRequest("GET", "/"), opts...)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				var data, _ = rsp.Data()
+				if string(data) != expect {
+					t.Errorf("expect %s, got %q", expect, data)
+				}
+			}(expect, client)
+		}
+	}
+	wg.Wait()
+	// requests with per-request authenticators are never merged
+	if n := atomic.LoadInt32(&count); n != 4 {
+		t.Errorf("expect 4 requests, got %d", n)
+	}
+}
@@ -0,0 +1,155 @@
+package restgo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const defaultKeyIDHeader = "X-Encrypt-Key-Id"
+
+// ErrPlaintextResponse 加密的请求收到了没有密钥ID的响应
+var ErrPlaintextResponse = errors.New("response is not encrypted")
+
+// BodyTransformer 请求/响应body转换，例如加密信封
+// EncodeRequest在body序列化之后、压缩和签名之前调用，可以修改请求header
+// DecodeResponse在响应验证之后、Response.Data返回之前调用
+type BodyTransformer interface {
+	EncodeRequest(req *http.Request, body []byte) ([]byte, error)
+	DecodeResponse(rsp *http.Response, body []byte) ([]byte, error)
+}
+
+// transformRequest 读取请求body，转换后替换为可重复读取的body
+func transformRequest(request *http.Request, transformer BodyTransformer) error {
+	var data, err = io.ReadAll(request.Body)
+	closeRequestBody(request)
+	if err != nil {
+		return err
+	}
+	data, err = transformer.EncodeRequest(request, data)
+	if err != nil {
+		return fmt.Errorf("restgo: encode request body: %w", err)
+	}
+	return NewBytesBody(data).apply(request)
+}
+
+// transformResponse 读取响应body，转换后作为Response.Data的结果
+func transformResponse(rsp *Response, transformer BodyTransformer) error {
+	var data, err = rsp.Data()
+	if err != nil {
+		return err
+	}
+	data, err = transformer.DecodeResponse(rsp.rsp, data)
+	if err != nil {
+		return fmt.Errorf("restgo: decode response body: %w", err)
+	}
+	rsp.data = data
+	rsp.rsp.Body = io.NopCloser(bytes.NewReader(data))
+	rsp.rsp.ContentLength = int64(len(data))
+	rsp.rsp.Header.Del("Content-Length")
+	return nil
+}
+
+// CipherFunc 由密钥创建分组密码，例如aes.NewCipher，SM4可使用第三方实现
+type CipherFunc func(key []byte) (cipher.Block, error)
+
+// GCMCodec GCM加密信封，请求body加密为{"nonce":"...","ciphertext":"..."}（base64），密钥ID通过header传递
+// 请求已加密而2xx响应没有密钥ID header时返回ErrPlaintextResponse，防止响应被降级为明文
+// 空body以及非2xx响应（例如网关返回的错误页）没有密钥ID header时原样返回
+type GCMCodec struct {
+	// KeyID 加密请求使用的密钥ID，为空时不加密请求
+	KeyID string
+	// Keys 按密钥ID查找密钥
+	Keys func(keyID string) ([]byte, error)
+	// NewCipher 分组密码，默认AES
+	NewCipher CipherFunc
+	// KeyIDHeader 密钥ID header，默认X-Encrypt-Key-Id
+	KeyIDHeader string
+	// AllowPlaintext 允许加密请求的响应不加密，没有密钥ID header的响应原样返回
+	AllowPlaintext bool
+}
+
+type gcmEnvelope struct {
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// NewGCMCodec 使用AES-GCM和静态密钥表的信封编解码器，keyID为加密请求使用的密钥
+func NewGCMCodec(keyID string, keys map[string][]byte) *GCMCodec {
+	return &GCMCodec{KeyID: keyID, Keys: func(id string) ([]byte, error) {
+		var key, ok = keys[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+		}
+		return key, nil
+	}}
+}
+
+func (c *GCMCodec) EncodeRequest(req *http.Request, body []byte) ([]byte, error) {
+	if c.KeyID == "" {
+		return body, nil
+	}
+	var aead, err = c.aead(c.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	var env = gcmEnvelope{Nonce: make([]byte, aead.NonceSize())}
+	_, err = rand.Read(env.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	env.Ciphertext = aead.Seal(nil, env.Nonce, body, nil)
+	req.Header.Set(headerOrDefault(c.KeyIDHeader, defaultKeyIDHeader), c.KeyID)
+	return json.Marshal(env)
+}
+
+func (c *GCMCodec) DecodeResponse(rsp *http.Response, body []byte) ([]byte, error) {
+	var header = headerOrDefault(c.KeyIDHeader, defaultKeyIDHeader)
+	var keyID = rsp.Header.Get(header)
+	if len(body) == 0 {
+		return body, nil
+	}
+	if keyID == "" {
+		if !c.AllowPlaintext && isSuccessStatus(rsp.StatusCode) &&
+			rsp.Request != nil && rsp.Request.Header.Get(header) != "" {
+			return nil, ErrPlaintextResponse
+		}
+		return body, nil
+	}
+	var aead, err = c.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+	var env gcmEnvelope
+	err = json.Unmarshal(body, &env)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size %d", len(env.Nonce))
+	}
+	return aead.Open(nil, env.Nonce, env.Ciphertext, nil)
+}
+
+func (c *GCMCodec) aead(keyID string) (cipher.AEAD, error) {
+	var key, err = c.Keys(keyID)
+	if err != nil {
+		return nil, err
+	}
+	var newCipher = c.NewCipher
+	if newCipher == nil {
+		newCipher = aes.NewCipher
+	}
+	var block cipher.Block
+	block, err = newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
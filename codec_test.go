@@ -0,0 +1,130 @@
+package restgo
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGCMCodec(t *testing.T) {
+	var keys = map[string][]byte{
+		"k1": []byte("0123456789abcdef"),
+		"k2": []byte("fedcba9876543210"),
+	}
+	// partner encrypts responses with k2
+	var server = NewGCMCodec("k2", keys)
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body, _ = io.ReadAll(r.Body)
+		var plain, err = server.DecodeResponse(&http.Response{Header: r.Header}, body)
+		if err != nil || r.Header.Get(defaultKeyIDHeader) != "k1" || strings.Contains(string(body), "name") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var req = map[string]string{}
+		_ = json.Unmarshal(plain, &req)
+		var rsp, _ = json.Marshal(map[string]string{"hello": req["name"]})
+		var fake = &http.Request{Header: w.Header()}
+		var enc, _ = server.EncodeRequest(fake, rsp)
+		_, _ = w.Write(enc)
+	}))
+	defer srv.Close()
+
+	var newCipherCalls int
+	var codec = NewGCMCodec("k1", keys)
+	// block cipher is pluggable, e.g. SM4
+	codec.NewCipher = func(key []byte) (cipher.Block, error) {
+		newCipherCalls++
+		return aes.NewCipher(key)
+	}
+	var hookData []byte
+	var c = New(WithBaseURL(srv.URL), WithBodyTransformer(codec), WithAfterHook(func(req IRequest, rsp IResponse) {
+		hookData, _ = rsp.Data()
+	}))
+	var rsp, err = c.Do(context.Background(), NewRequest("POST", "/").SetJSONBody(map[string]string{"name": "restgo"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]string
+	err = rsp.JSONUnmarshal(&out)
+	if err != nil || out["hello"] != "restgo" || newCipherCalls != 2 {
+		t.Errorf("unexpected response %v %v %d", out, err, newCipherCalls)
+	}
+	// after hooks see the decoded body
+	if string(hookData) != `{"hello":"restgo"}` {
+		t.Errorf("unexpected hook data %s", hookData)
+	}
+}
+
+func TestGCMCodec_Downgrade(t *testing.T) {
+	var keys = map[string][]byte{"k1": []byte("0123456789abcdef")}
+	var server = NewGCMCodec("k1", keys)
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/plaintext":
+			_, _ = w.Write([]byte(`{"hello":"plaintext"}`))
+			return
+		case "/nocontent":
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case "/badgateway":
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("<html>bad gateway</html>"))
+			return
+		case "/unknown":
+			w.Header().Set(defaultKeyIDHeader, "k9")
+			_, _ = w.Write([]byte(`{"nonce":"","ciphertext":""}`))
+			return
+		}
+		var fake = &http.Request{Header: w.Header()}
+		var enc, _ = server.EncodeRequest(fake, []byte(`{"hello":"encrypted"}`))
+		var env gcmEnvelope
+		_ = json.Unmarshal(enc, &env)
+		env.Ciphertext[0] ^= 0xff
+		enc, _ = json.Marshal(env)
+		_, _ = w.Write(enc)
+	}))
+	defer srv.Close()
+
+	var codec = NewGCMCodec("k1", keys)
+	var c = New(WithBaseURL(srv.URL), WithBodyTransformer(codec))
+	var expects = map[string]error{"/plaintext": ErrPlaintextResponse, "/unknown": ErrUnknownKey, "/tampered": nil}
+	for path, expect := range expects {
+		var rsp, err = c.Do(context.Background(), NewRequest("POST", path).SetJSONBody(map[string]string{"name": "restgo"}))
+		if err == nil {
+			_, err = rsp.Data()
+		}
+		if err == nil || (expect != nil && !errors.Is(err, expect)) {
+			t.Errorf("%s: expect %v, got %v", path, expect, err)
+		}
+	}
+
+	// empty bodies and plaintext error pages are not downgrades, status errors still surface
+	var rsp, err = c.Do(context.Background(), NewRequest("POST", "/nocontent").SetJSONBody(map[string]string{"name": "restgo"}))
+	if err != nil || rsp.StatusCode() != http.StatusNoContent {
+		t.Errorf("expect 204, got %v %v", rsp, err)
+	}
+	_, err = c.Do(context.Background(), NewRequest("POST", "/badgateway").SetJSONBody(map[string]string{"name": "restgo"}),
+		WithRequestNon2xxError(true))
+	if !IsServerError(err) {
+		t.Errorf("expect server error, got %v", err)
+	}
+
+	// plaintext responses are accepted only when explicitly allowed
+	codec.AllowPlaintext = true
+	rsp, err = c.Do(context.Background(), NewRequest("POST", "/plaintext").SetJSONBody(map[string]string{"name": "restgo"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	data, err = rsp.Data()
+	if err != nil || string(data) != `{"hello":"plaintext"}` {
+		t.Errorf("unexpected response %s %v", data, err)
+	}
+}
@@ -0,0 +1,238 @@
+package restgo
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const headerContentEncoding = "Content-Encoding"
+
+// DecoderFunc 创建解压reader
+type DecoderFunc func(r io.Reader) (io.ReadCloser, error)
+
+// EncoderFunc 创建压缩writer
+type EncoderFunc func(w io.Writer) (io.WriteCloser, error)
+
+var (
+	codecLock = sync.RWMutex{}
+	decoders  = map[string]DecoderFunc{
+		"gzip":    gzipDecoder,
+		"x-gzip":  gzipDecoder,
+		"deflate": deflateDecoder,
+		"br":      brotliDecoder,
+		"zstd":    zstdDecoder,
+	}
+	encoders = map[string]EncoderFunc{
+		"gzip": func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		},
+		"deflate": func(w io.Writer) (io.WriteCloser, error) {
+			return zlib.NewWriter(w), nil
+		},
+		"br": func(w io.Writer) (io.WriteCloser, error) {
+			return brotli.NewWriter(w), nil
+		},
+		"zstd": func(w io.Writer) (io.WriteCloser, error) {
+			return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+		},
+	}
+)
+
+// RegisterDecoder 注册响应解压方式，内置gzip、deflate、br和zstd，已存在的编码会被替换
+func RegisterDecoder(encoding string, decoder DecoderFunc) {
+	codecLock.Lock()
+	defer codecLock.Unlock()
+	decoders[strings.ToLower(encoding)] = decoder
+}
+
+// RegisterEncoder 注册请求压缩方式，内置gzip、deflate、br和zstd
+func RegisterEncoder(encoding string, encoder EncoderFunc) {
+	codecLock.Lock()
+	defer codecLock.Unlock()
+	encoders[strings.ToLower(encoding)] = encoder
+}
+
+func getDecoder(encoding string) (DecoderFunc, bool) {
+	codecLock.RLock()
+	defer codecLock.RUnlock()
+	var fn, ok = decoders[strings.ToLower(encoding)]
+	return fn, ok
+}
+
+func getEncoder(encoding string) (EncoderFunc, bool) {
+	codecLock.RLock()
+	defer codecLock.RUnlock()
+	var fn, ok = encoders[strings.ToLower(encoding)]
+	return fn, ok
+}
+
+func gzipDecoder(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func brotliDecoder(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(brotli.NewReader(r)), nil
+}
+
+// zstdDecoder 单线程解码，Close时释放解码器
+func zstdDecoder(r io.Reader) (io.ReadCloser, error) {
+	var d, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
+
+// deflateDecoder deflate编码按规范为zlib格式，但部分服务端发送裸deflate，两者都支持
+func deflateDecoder(r io.Reader) (io.ReadCloser, error) {
+	var br = bufio.NewReader(r)
+	var header, err = br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decodeResponse 按Content-Encoding解压响应body，存在未注册的编码时保持原样
+func decodeResponse(rsp *http.Response) {
+	var encodings = contentEncodings(rsp.Header)
+	if len(encodings) == 0 || rsp.Body == nil || rsp.Body == http.NoBody {
+		return
+	}
+	var fns = make([]DecoderFunc, 0, len(encodings))
+	// encodings are listed in the order they were applied
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var fn, ok = getDecoder(encodings[i])
+		if !ok {
+			return
+		}
+		fns = append(fns, fn)
+	}
+	var body = &decodedBody{raw: rsp.Body, decoders: fns}
+	rsp.Body = body
+	rsp.Header.Del(headerContentEncoding)
+	rsp.Header.Del("Content-Length")
+	rsp.ContentLength = -1
+	rsp.Uncompressed = true
+}
+
+func contentEncodings(header http.Header) []string {
+	var encodings []string
+	for _, v := range header.Values(headerContentEncoding) {
+		for _, e := range strings.Split(v, ",") {
+			e = strings.TrimSpace(e)
+			if e != "" && !strings.EqualFold(e, "identity") {
+				encodings = append(encodings, e)
+			}
+		}
+	}
+	return encodings
+}
+
+// decodedBody 首次读取时才创建解压reader，避免读取空body时出错
+type decodedBody struct {
+	raw      io.ReadCloser
+	decoders []DecoderFunc
+	reader   io.Reader
+	closers  []io.Closer
+	err      error
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		var r io.Reader = b.raw
+		for _, fn := range b.decoders {
+			var rc, err = fn(r)
+			if err != nil {
+				b.err = err
+				break
+			}
+			b.closers = append(b.closers, rc)
+			r = rc
+		}
+		b.reader = r
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.reader.Read(p)
+}
+
+func (b *decodedBody) Close() error {
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		_ = b.closers[i].Close()
+	}
+	return b.raw.Close()
+}
+
+// compressRequest 压缩请求body并设置Content-Encoding
+// 已设置Content-Encoding、没有body或body长度小于threshold时不压缩，长度未知时压缩
+func compressRequest(req *http.Request, encoding string, threshold int64) error {
+	if req.Body == nil || req.Body == http.NoBody || req.Header.Get(headerContentEncoding) != "" {
+		return nil
+	}
+	if req.ContentLength > 0 && req.ContentLength < threshold {
+		return nil
+	}
+	var encoder, ok = getEncoder(encoding)
+	if !ok {
+		return &BuildError{Op: "compress", Err: errors.New("unknown content encoding " + encoding)}
+	}
+	req.Body = &compressedBody{src: req.Body, encoder: encoder}
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			var src, err = getBody()
+			if err != nil {
+				return nil, err
+			}
+			return &compressedBody{src: src, encoder: encoder}, nil
+		}
+	}
+	req.ContentLength = -1
+	req.Header.Set(headerContentEncoding, encoding)
+	return nil
+}
+
+// compressedBody 压缩src，首次读取时才启动压缩goroutine，未发送的请求不占用goroutine
+type compressedBody struct {
+	src     io.ReadCloser
+	encoder EncoderFunc
+	reader  *io.PipeReader
+}
+
+func (b *compressedBody) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		var pr, pw = io.Pipe()
+		b.reader = pr
+		go func() {
+			defer b.src.Close()
+			var w, err = b.encoder(pw)
+			if err == nil {
+				_, err = io.Copy(w, b.src)
+				if cErr := w.Close(); err == nil {
+					err = cErr
+				}
+			}
+			pw.CloseWithError(err)
+		}()
+	}
+	return b.reader.Read(p)
+}
+
+// Close 未读取时直接关闭src，否则关闭pipe，压缩goroutine写入失败后关闭src
+func (b *compressedBody) Close() error {
+	if b.reader == nil {
+		return b.src.Close()
+	}
+	return b.reader.Close()
+}
@@ -0,0 +1,80 @@
+package restgo
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestClient_Decompression(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf = &bytes.Buffer{}
+		var zw io.WriteCloser
+		switch r.URL.Path {
+		case "/gzip":
+			zw = gzip.NewWriter(buf)
+		case "/deflate":
+			zw, _ = flate.NewWriter(buf, flate.DefaultCompression)
+		case "/br":
+			zw = brotli.NewWriter(buf)
+		case "/zstd":
+			zw, _ = zstd.NewWriter(buf)
+		}
+		_, _ = zw.Write([]byte("compressed"))
+		_ = zw.Close()
+		w.Header().Set(headerContentEncoding, strings.TrimPrefix(r.URL.Path, "/"))
+		_, _ = w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithHeader("Accept-Encoding", "gzip, deflate, br, zstd"))
+	for _, resource := range []string{"/gzip", "/deflate", "/br", "/zstd"} {
+		var rsp, err = c.Get(context.Background(), resource)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data []byte
+		data, err = rsp.Data()
+		if err != nil || string(data) != "compressed" {
+			t.Errorf("unexpected response of %s: %q %v", resource, data, err)
+		}
+	}
+}
+
+func TestClient_RequestCompression(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(headerContentEncoding) != "gzip" {
+			_, _ = io.Copy(w, r.Body)
+			return
+		}
+		var zr, err = gzip.NewReader(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_, _ = w.Write([]byte("gzip:"))
+		_, _ = io.Copy(w, zr)
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithRequestCompression("gzip", 8))
+	var expects = map[string]string{"small": "small", "large body": "gzip:large body"}
+	for body, expect := range expects {
+		var rsp, err = c.Post(context.Background(), "/", NewBodyParam("text/plain", strings.NewReader(body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data []byte
+		data, err = rsp.Data()
+		if err != nil || string(data) != expect {
+			t.Errorf("expect %s, got %q %v", expect, data, err)
+		}
+	}
+}
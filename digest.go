@@ -0,0 +1,255 @@
+package restgo
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const headerWWWAuthenticate = "WWW-Authenticate"
+
+// DigestAuthenticator HTTP Digest认证（RFC 7616），支持MD5、SHA-256及其-sess变体，qop=auth/auth-int
+// 首次请求收到401质询后计算响应并重发，之后按host缓存nonce并递增nonce count，后续请求直接携带认证信息
+type DigestAuthenticator struct {
+	username string
+	password string
+
+	lock       sync.Mutex
+	challenges map[string]*digestChallenge
+}
+
+// DigestAuth 新建Digest认证器，同一个认证器在其所属Client的请求间共享nonce
+func DigestAuth(username, password string) *DigestAuthenticator {
+	return &DigestAuthenticator{
+		username:   username,
+		password:   password,
+		challenges: map[string]*digestChallenge{},
+	}
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       []string
+	userhash  bool
+	stale     bool
+	nc        uint32
+}
+
+func (d *DigestAuthenticator) Authenticate(ctx context.Context, req *http.Request) error {
+	d.lock.Lock()
+	var ch, ok = d.challenges[req.URL.Host]
+	var nc uint32
+	var challenge digestChallenge
+	if ok {
+		ch.nc++
+		nc = ch.nc
+		challenge = *ch
+	}
+	d.lock.Unlock()
+	if !ok {
+		return nil
+	}
+	var auth, err = d.authorization(req, &challenge, nc, randomString()[:16])
+	if err != nil {
+		return err
+	}
+	req.Header.Set(headerAuthorization, auth)
+	return nil
+}
+
+// HandleUnauthorized 解析Digest质询并缓存，请求已使用该nonce且质询未标记stale时说明凭证错误，不再重发
+func (d *DigestAuthenticator) HandleUnauthorized(ctx context.Context, req *http.Request, rsp *http.Response) bool {
+	var challenge = parseDigestChallenge(rsp.Header.Values(headerWWWAuthenticate))
+	if challenge == nil {
+		return false
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	var used = strings.Contains(req.Header.Get(headerAuthorization), `nonce="`+challenge.nonce+`"`)
+	if challenge.nonce == "" || used && !challenge.stale {
+		delete(d.challenges, req.URL.Host)
+		return false
+	}
+	d.challenges[req.URL.Host] = challenge
+	return true
+}
+
+func (d *DigestAuthenticator) authorization(req *http.Request, ch *digestChallenge, nc uint32, cnonce string) (string, error) {
+	var newHash = digestHash(ch.algorithm)
+	if newHash == nil {
+		return "", fmt.Errorf("restgo: digest: unsupported algorithm %s", ch.algorithm)
+	}
+	var h = func(s string) string {
+		var hh = newHash()
+		_, _ = io.WriteString(hh, s)
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+	var ncValue = fmt.Sprintf("%08x", nc)
+	var uri = req.URL.RequestURI()
+
+	var ha1 = h(d.username + ":" + ch.realm + ":" + d.password)
+	if strings.HasSuffix(strings.ToUpper(ch.algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + ch.nonce + ":" + cnonce)
+	}
+	var qop = ch.selectQop(req)
+	var ha2 = h(req.Method + ":" + uri)
+	if qop == "auth-int" {
+		var bodyHash, err = digestBodyHash(req, newHash)
+		if err != nil {
+			return "", err
+		}
+		ha2 = h(req.Method + ":" + uri + ":" + bodyHash)
+	}
+	var response string
+	if qop == "" {
+		response = h(ha1 + ":" + ch.nonce + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + ch.nonce + ":" + ncValue + ":" + cnonce + ":" + qop + ":" + ha2)
+	}
+
+	var username = d.username
+	if ch.userhash {
+		username = h(d.username + ":" + ch.realm)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `Digest username="%s", realm="%s", nonce="%s", uri="%s"`, username, ch.realm, ch.nonce, uri)
+	if ch.algorithm != "" {
+		fmt.Fprintf(&b, `, algorithm=%s`, ch.algorithm)
+	}
+	fmt.Fprintf(&b, `, response="%s"`, response)
+	if ch.opaque != "" {
+		fmt.Fprintf(&b, `, opaque="%s"`, ch.opaque)
+	}
+	if qop != "" {
+		fmt.Fprintf(&b, `, qop=%s, nc=%s, cnonce="%s"`, qop, ncValue, cnonce)
+	}
+	if ch.userhash {
+		b.WriteString(`, userhash=true`)
+	}
+	return b.String(), nil
+}
+
+// selectQop 优先使用auth，仅支持auth-int且body可重复读取时使用auth-int
+func (ch *digestChallenge) selectQop(req *http.Request) string {
+	var authInt bool
+	for _, q := range ch.qop {
+		switch q {
+		case "auth":
+			return q
+		case "auth-int":
+			authInt = true
+		}
+	}
+	if authInt && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil) {
+		return "auth-int"
+	}
+	return ""
+}
+
+func digestBodyHash(req *http.Request, newHash func() hash.Hash) (string, error) {
+	if req.GetBody == nil {
+		return hex.EncodeToString(newHash().Sum(nil)), nil
+	}
+	var sum, err = hashBody(newHash, req.GetBody)
+	if err != nil {
+		return "", err
+	}
+	// seeker body shares one reader with GetBody
+	closeRequestBody(req)
+	req.Body, err = req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "", "MD5":
+		return md5.New
+	case "SHA-256":
+		return sha256.New
+	}
+	return nil
+}
+
+// parseDigestChallenge 解析WWW-Authenticate中的Digest质询，存在多个时优先SHA-256
+func parseDigestChallenge(values []string) *digestChallenge {
+	var selected *digestChallenge
+	for _, v := range values {
+		var scheme, params = parseAuthParams(v)
+		if !strings.EqualFold(scheme, "Digest") || digestHash(params["algorithm"]) == nil {
+			continue
+		}
+		var ch = &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+			userhash:  strings.EqualFold(params["userhash"], "true"),
+			stale:     strings.EqualFold(params["stale"], "true"),
+		}
+		for _, q := range strings.Split(params["qop"], ",") {
+			if q = strings.TrimSpace(q); q != "" {
+				ch.qop = append(ch.qop, q)
+			}
+		}
+		if selected == nil || strings.HasPrefix(strings.ToUpper(ch.algorithm), "SHA-256") {
+			selected = ch
+		}
+	}
+	return selected
+}
+
+// parseAuthParams 解析`scheme k1=v1, k2="v2"`形式的质询，参数名转为小写
+func parseAuthParams(s string) (string, map[string]string) {
+	s = strings.TrimSpace(s)
+	var scheme = s
+	var i = strings.IndexByte(s, ' ')
+	if i < 0 {
+		return scheme, nil
+	}
+	scheme, s = s[:i], s[i+1:]
+	var params = map[string]string{}
+	for {
+		s = strings.TrimLeft(s, " ,")
+		var eq = strings.IndexByte(s, '=')
+		if eq < 0 {
+			return scheme, params
+		}
+		var key = strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " ")
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			var j = 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			if j < len(s) {
+				j++
+			}
+			value, s = b.String(), s[j:]
+		} else {
+			var end = strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = strings.TrimSpace(s[:end]), s[end:]
+		}
+		params[key] = value
+	}
+}
@@ -0,0 +1,99 @@
+package restgo
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestDigestAuth_RFC7616 example of RFC 7616 section 3.9.1
+func TestDigestAuth_RFC7616(t *testing.T) {
+	var d = DigestAuth("Mufasa", "Circle of Life")
+	var header = `Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=%s, ` +
+		`nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`
+	var cases = map[string]string{
+		"MD5":     "8ca523f5e9506fed4657c9700eebdbec",
+		"SHA-256": "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+	}
+	for algorithm, expect := range cases {
+		var ch = parseDigestChallenge([]string{strings.Replace(header, "%s", algorithm, 1)})
+		var req, _ = http.NewRequest("GET", "http://www.example.org/dir/index.html", nil)
+		var auth, err = d.authorization(req, ch, 1, "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ")
+		if err != nil || !strings.Contains(auth, `response="`+expect+`"`) || !strings.Contains(auth, "nc=00000001") {
+			t.Errorf("%s: unexpected authorization %s %v", algorithm, auth, err)
+		}
+	}
+}
+
+func TestDigestAuth_Client(t *testing.T) {
+	var challenges int32
+	var lastNC string
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body, _ = io.ReadAll(r.Body)
+		var algorithm, qop, nonce = "SHA-256", "auth", "n1"
+		if r.URL.Path == "/int" {
+			algorithm, qop, nonce = "MD5", "auth-int", "n2"
+		}
+		var _, params = parseAuthParams(r.Header.Get(headerAuthorization))
+		if params["nonce"] != nonce || params["response"] != expectDigest(r, params, body, algorithm) {
+			atomic.AddInt32(&challenges, 1)
+			w.Header().Add(headerWWWAuthenticate, `Digest realm="test", qop="`+qop+`", nonce="`+nonce+`", opaque="o", algorithm=`+algorithm)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		lastNC = params["nc"]
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithAuthenticator(DigestAuth("user", "pass")))
+	for i, req := range []IRequest{
+		NewRequest("GET", "/a"),
+		NewRequest("POST", "/a").SetJSONBody(map[string]int{"a": 1}),
+	} {
+		var rsp, err = c.Do(context.Background(), req)
+		if err != nil || rsp.StatusCode() != http.StatusOK {
+			t.Fatalf("request %d failed: %v %v", i, rsp, err)
+		}
+	}
+	// nonce is cached, the second request is not challenged
+	if challenges != 1 || lastNC != "00000002" {
+		t.Errorf("expect 1 challenge and nc 2, got %d %s", challenges, lastNC)
+	}
+
+	var rsp, err = c.Post(context.Background(), "/int", NewBodyParam("text/plain", strings.NewReader("body")))
+	if err != nil || rsp.StatusCode() != http.StatusOK {
+		t.Errorf("auth-int request failed: %v %v", rsp, err)
+	}
+
+	rsp, err = New(WithBaseURL(srv.URL), WithAuthenticator(DigestAuth("user", "wrong"))).Get(context.Background(), "/a")
+	if err != nil || rsp.StatusCode() != http.StatusUnauthorized {
+		t.Errorf("expect 401 with wrong password, got %v %v", rsp, err)
+	}
+}
+
+func expectDigest(r *http.Request, params map[string]string, body []byte, algorithm string) string {
+	var newHash func() hash.Hash = sha256.New
+	if algorithm == "MD5" {
+		newHash = md5.New
+	}
+	var h = func(parts ...string) string {
+		var hh = newHash()
+		_, _ = io.WriteString(hh, strings.Join(parts, ":"))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+	var ha2 = h(r.Method, params["uri"])
+	if params["qop"] == "auth-int" {
+		var hh = newHash()
+		_, _ = hh.Write(body)
+		ha2 = h(r.Method, params["uri"], hex.EncodeToString(hh.Sum(nil)))
+	}
+	return h(h("user", "test", "pass"), params["nonce"], params["nc"], params["cnonce"], params["qop"], ha2)
+}
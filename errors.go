@@ -0,0 +1,106 @@
+package restgo
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// maxErrorBodySize HTTPError中保留的响应body最大长度
+const maxErrorBodySize = 4096
+
+// HTTPError 非2xx响应对应的错误
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Header     http.Header
+	// Body 响应body片段，最多保留maxErrorBodySize字节
+	Body []byte
+}
+
+// NewHTTPError 根据响应构造HTTPError
+// 如果响应body尚未读取，最多读取maxErrorBodySize字节后关闭body
+// URL为认证前的请求URL，不包含APIKeyQuery等认证器添加的凭证
+func NewHTTPError(rsp IResponse) *HTTPError {
+	var r = rsp.GetResponse()
+	var e = &HTTPError{
+		StatusCode: r.StatusCode,
+		Status:     r.Status,
+		Header:     r.Header,
+	}
+	if r.Request != nil {
+		e.Method = r.Request.Method
+		if r.Request.URL != nil {
+			e.URL = r.Request.URL.Redacted()
+		}
+	}
+	var o, ok = rsp.(*Response)
+	if ok && o.url != "" {
+		e.URL = o.url
+	}
+	if ok && o.data != nil {
+		e.Body = o.data
+	} else if r.Body != nil {
+		e.Body, _ = io.ReadAll(io.LimitReader(r.Body, maxErrorBodySize))
+		_ = r.Body.Close()
+	}
+	if len(e.Body) > maxErrorBodySize {
+		e.Body = e.Body[:maxErrorBodySize]
+	}
+	return e
+}
+
+func (e *HTTPError) Error() string {
+	var msg = "restgo: "
+	if e.Method != "" {
+		msg += e.Method + " " + e.URL + ": "
+	}
+	if e.Status != "" {
+		msg += e.Status
+	} else {
+		msg += strconv.Itoa(e.StatusCode)
+	}
+	if len(e.Body) != 0 {
+		msg += ": " + string(e.Body)
+	}
+	return msg
+}
+
+// AsHTTPError 从错误链中取出HTTPError
+func AsHTTPError(err error) (*HTTPError, bool) {
+	var e *HTTPError
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// IsStatus 错误是否为指定状态码的HTTPError
+func IsStatus(err error, code int) bool {
+	var e, ok = AsHTTPError(err)
+	return ok && e.StatusCode == code
+}
+
+// IsNotFound 错误是否为404
+func IsNotFound(err error) bool {
+	return IsStatus(err, http.StatusNotFound)
+}
+
+// IsClientError 错误是否为4xx
+func IsClientError(err error) bool {
+	var e, ok = AsHTTPError(err)
+	return ok && e.StatusCode >= 400 && e.StatusCode < 500
+}
+
+// IsServerError 错误是否为5xx
+func IsServerError(err error) bool {
+	var e, ok = AsHTTPError(err)
+	return ok && e.StatusCode >= 500 && e.StatusCode < 600
+}
+
+func isSuccessStatus(code int) bool {
+	return code >= 200 && code < 300
+}
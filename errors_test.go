@@ -0,0 +1,57 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_Non2xxError(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("maintenance"))
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithNon2xxError(true))
+	var _, err = c.Get(context.Background(), "/status")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("expect HTTPError, got %v", err)
+	}
+	if !IsServerError(err) || IsClientError(err) || httpErr.Method != "GET" || string(httpErr.Body) != "maintenance" {
+		t.Errorf("unexpected HTTPError: %+v", httpErr)
+	}
+
+	var req = NewRequest("GET", "/status").WithNon2xxError(false)
+	var rsp IResponse
+	rsp, err = c.Do(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rsp.ExplicitCloseBody()
+	if rsp.StatusCode() != http.StatusServiceUnavailable {
+		t.Errorf("unexpected status: %d", rsp.StatusCode())
+	}
+}
+
+func TestClient_Non2xxErrorRedactsCredentials(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != "SECRET" {
+			t.Errorf("expect api key in request, got %s", r.URL.RawQuery)
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithNon2xxError(true), WithAuthenticator(APIKeyQuery("api_key", "SECRET")))
+	var _, err = c.Do(context.Background(), NewRequest("GET", "/x").AddURLQuery("page", "1"))
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("expect HTTPError, got %v", err)
+	}
+	if strings.Contains(err.Error(), "SECRET") || httpErr.URL != srv.URL+"/x?page=1" {
+		t.Errorf("unexpected HTTPError: %s", err)
+	}
+}
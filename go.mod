@@ -1,9 +1,19 @@
 module github.com/pinealctx/restgo
 
-go 1.16
+go 1.20
 
 require (
+	github.com/andybalholm/brotli v1.1.0
 	github.com/fatih/structtag v1.2.0
+	github.com/klauspost/compress v1.17.4
 	github.com/pinealctx/neptune v1.2.4
 	go.uber.org/zap v1.26.0
 )
+
+require (
+	github.com/golang/snappy v0.0.4 // indirect
+	github.com/json-iterator/go v1.1.12 // indirect
+	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
+	github.com/modern-go/reflect2 v1.0.2 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+)
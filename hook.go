@@ -5,3 +5,7 @@ type BeforeHookFunc func(req IRequest)
 
 // AfterHookFunc 请求后钩子函数
 type AfterHookFunc func(req IRequest, rsp IResponse)
+
+// RetryHookFunc 重试钩子函数，第attempt次请求失败并即将重试时调用
+// rsp和err至多一个非nil，钩子中无需关闭rsp的body
+type RetryHookFunc func(req IRequest, attempt int, rsp IResponse, err error)
@@ -0,0 +1,54 @@
+package restgo
+
+import (
+	"context"
+	"net/http"
+)
+
+// Handler 发送http请求
+type Handler func(ctx context.Context, req *http.Request) (*http.Response, error)
+
+// Middleware 中间件，可以修改请求、中止请求、替换响应以及观察错误
+type Middleware func(next Handler) Handler
+
+// Chain 组合多个中间件，第一个中间件位于最外层
+func Chain(mws ...Middleware) Middleware {
+	return func(next Handler) Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			next = mws[i](next)
+		}
+		return next
+	}
+}
+
+type callStateKey struct{}
+
+// callState 单次Do调用的状态
+type callState struct {
+	req       IRequest
+	opt       *requestOption
+	attempts  int
+	fromCache bool
+	// body replayable request body, its underlying source is released when Do returns
+	body *ReplayableBody
+}
+
+func (s *callState) releaseBody() {
+	if s.body != nil {
+		s.body.release()
+	}
+}
+
+func getCallState(ctx context.Context) *callState {
+	var state, ok = ctx.Value(callStateKey{}).(*callState)
+	if !ok {
+		// handler invoked outside Client.Do
+		return &callState{}
+	}
+	return state
+}
+
+// RequestFromContext 获取中间件当前处理的IRequest，不在Client.Do中时返回nil
+func RequestFromContext(ctx context.Context) IRequest {
+	return getCallState(ctx).req
+}
@@ -0,0 +1,102 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Middleware(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Trace") + "," + r.URL.Query().Get("hook")))
+	}))
+	defer srv.Close()
+	var abortErr = errors.New("abort")
+	var hookData string
+	var c = New(
+		WithBaseURL(srv.URL),
+		WithBeforeHook(func(req IRequest) {
+			req.AddURLQuery("hook", "before")
+		}),
+		WithAfterHook(func(req IRequest, rsp IResponse) {
+			var data, _ = rsp.Data()
+			hookData = string(data)
+		}),
+		WithMiddleware(func(next Handler) Handler {
+			return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				switch req.URL.Path {
+				case "/abort":
+					return nil, abortErr
+				case "/mock":
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Header:     http.Header{},
+						Body:       io.NopCloser(strings.NewReader("mock")),
+						Request:    req,
+					}, nil
+				}
+				if RequestFromContext(ctx) == nil {
+					t.Error("expect IRequest in context")
+				}
+				req.Header.Set("X-Trace", "mw")
+				return next(ctx, req)
+			}
+		}),
+	)
+	var expects = map[string]string{"/": "mw,before", "/mock": "mock"}
+	for resource, expect := range expects {
+		var rsp, err = c.Get(context.Background(), resource)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data []byte
+		data, err = rsp.Data()
+		if err != nil || string(data) != expect || hookData != expect {
+			t.Errorf("expect %s, got %q %q %v", expect, data, hookData, err)
+		}
+	}
+	var _, err = c.Get(context.Background(), "/abort")
+	if !errors.Is(err, abortErr) {
+		t.Errorf("expect abort error, got %v", err)
+	}
+}
+
+func TestClient_BeforeHookOneShotBody(t *testing.T) {
+	var count int32
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body, _ = io.ReadAll(r.Body)
+		if string(body) != "payload" || r.Header.Get("X-Hook") != "1" {
+			t.Errorf("unexpected request: %q %s", body, r.Header.Get("X-Hook"))
+		}
+		if atomic.AddInt32(&count, 1) < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	var policy = DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	var hooks int
+	var c = New(WithBaseURL(srv.URL), WithRetry(policy), WithBeforeHook(func(req IRequest) {
+		hooks++
+		req.AddHeader("X-Hook", "1")
+	}))
+	// io.MultiReader is neither a seeker nor a closer, it can be read only once
+	var req = NewRequest("PUT", "/").SetBody("text/plain", io.MultiReader(strings.NewReader("payload")))
+	var rsp, err = c.Do(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	data, err = rsp.Data()
+	if err != nil || string(data) != "payload" || hooks != 1 || count != 2 {
+		t.Errorf("unexpected result %q %d %d %v", data, hooks, count, err)
+	}
+}
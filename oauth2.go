@@ -0,0 +1,303 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultExpiryDelta    = 30 * time.Second
+	defaultRefreshTimeout = 30 * time.Second
+)
+
+// OAuth2Token OAuth2访问令牌
+type OAuth2Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	// Expiry 过期时间，零值表示不过期
+	Expiry time.Time `json:"expiry,omitempty"`
+}
+
+// Valid 令牌在delta时间后是否仍然有效
+func (t *OAuth2Token) Valid(delta time.Duration) bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Now().Add(delta).Before(t.Expiry)
+}
+
+// AuthorizationHeader Authorization头的值，令牌类型缺省为Bearer
+func (t *OAuth2Token) AuthorizationHeader() string {
+	var typ = t.TokenType
+	if typ == "" || strings.EqualFold(typ, "bearer") {
+		typ = "Bearer"
+	}
+	return typ + " " + t.AccessToken
+}
+
+// OAuth2Error 令牌端点返回的错误（RFC 6749 5.2）
+type OAuth2Error struct {
+	StatusCode  int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	URI         string `json:"error_uri,omitempty"`
+}
+
+func (e *OAuth2Error) Error() string {
+	var msg = "restgo: oauth2: " + e.Code
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// OAuth2Config OAuth2客户端配置
+type OAuth2Config struct {
+	ClientID     string
+	ClientSecret string
+	TokenURL     string
+	// AuthURL 授权码模式的授权端点
+	AuthURL string
+	// DeviceAuthURL 设备码模式的设备授权端点
+	DeviceAuthURL string
+	Scopes        []string
+	// EndpointParams 请求令牌时附加的参数，例如audience
+	EndpointParams url.Values
+	// AuthInParams 客户端凭证放在请求参数中，默认使用HTTP Basic认证
+	AuthInParams bool
+	// ExpiryDelta 提前多久刷新令牌，默认30s
+	ExpiryDelta time.Duration
+	// RefreshTimeout 单次刷新令牌的超时时间，默认30s
+	// 刷新由所有等待令牌的调用方共享，不随发起刷新的调用方取消
+	RefreshTimeout time.Duration
+	// Client 请求令牌使用的客户端，默认DefaultClient
+	Client *Client
+	// Store 持久化令牌，获取到新令牌时保存
+	Store TokenStore
+}
+
+// OAuth2TokenSource 获取并缓存OAuth2令牌，令牌过期前自动刷新，并发刷新只会请求一次
+// 同时实现了Authenticator，收到401时废弃当前令牌并重发一次请求
+type OAuth2TokenSource struct {
+	cfg   OAuth2Config
+	fetch func(ctx context.Context, current *OAuth2Token) (*OAuth2Token, error)
+
+	lock       sync.Mutex
+	token      *OAuth2Token
+	refreshing *tokenRefresh
+}
+
+// tokenRefresh 进行中的刷新，done关闭后token和err可读
+type tokenRefresh struct {
+	done  chan struct{}
+	token *OAuth2Token
+	err   error
+}
+
+// NewClientCredentialsSource client credentials授权（RFC 6749 4.4）
+func NewClientCredentialsSource(cfg OAuth2Config) *OAuth2TokenSource {
+	var s = &OAuth2TokenSource{cfg: cfg}
+	s.fetch = func(ctx context.Context, _ *OAuth2Token) (*OAuth2Token, error) {
+		var params = url.Values{"grant_type": {"client_credentials"}}
+		if len(cfg.Scopes) != 0 {
+			params.Set("scope", strings.Join(cfg.Scopes, " "))
+		}
+		return cfg.requestToken(ctx, params)
+	}
+	return s
+}
+
+// NewRefreshTokenSource refresh token授权（RFC 6749 6），token为已有的令牌，可以只包含RefreshToken
+// token为nil时从cfg.Store加载，服务端返回新的refresh token时自动替换
+func NewRefreshTokenSource(cfg OAuth2Config, token *OAuth2Token) *OAuth2TokenSource {
+	var s = &OAuth2TokenSource{cfg: cfg, token: token}
+	s.fetch = func(ctx context.Context, current *OAuth2Token) (*OAuth2Token, error) {
+		if current == nil && cfg.Store != nil {
+			var stored, err = cfg.Store.Load()
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			current = stored
+		}
+		if current.Valid(cfg.expiryDelta()) {
+			return current, nil
+		}
+		if current == nil || current.RefreshToken == "" {
+			return nil, &OAuth2Error{Code: "invalid_grant", Description: "refresh token is missing"}
+		}
+		var params = url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {current.RefreshToken},
+		}
+		var token, err = cfg.requestToken(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		if token.RefreshToken == "" {
+			token.RefreshToken = current.RefreshToken
+		}
+		return token, nil
+	}
+	return s
+}
+
+// Token 获取访问令牌，实现TokenSource
+func (s *OAuth2TokenSource) Token(ctx context.Context) (string, error) {
+	var token, err = s.OAuth2Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// OAuth2Token 获取有效的令牌，即将过期时刷新
+func (s *OAuth2TokenSource) OAuth2Token(ctx context.Context) (*OAuth2Token, error) {
+	s.lock.Lock()
+	if s.token.Valid(s.cfg.expiryDelta()) {
+		var token = s.token
+		s.lock.Unlock()
+		return token, nil
+	}
+	var refresh = s.refreshing
+	if refresh == nil {
+		refresh = s.startRefresh(ctx)
+	}
+	s.lock.Unlock()
+	select {
+	case <-refresh.done:
+		return refresh.token, refresh.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// startRefresh 调用方持有锁，刷新在独立的goroutine中进行，使用保留ctx的值但不随其取消的context
+func (s *OAuth2TokenSource) startRefresh(ctx context.Context) *tokenRefresh {
+	var refresh = &tokenRefresh{done: make(chan struct{})}
+	var current = s.token
+	s.refreshing = refresh
+	go func() {
+		var fetchCtx, cancel = context.WithTimeout(withoutCancel(ctx), s.cfg.refreshTimeout())
+		defer cancel()
+		var token, err = s.fetch(fetchCtx, current)
+		if err == nil && token != current {
+			err = saveToken(s.cfg.Store, token)
+		}
+		s.lock.Lock()
+		if token != nil {
+			s.token = token
+		}
+		s.refreshing = nil
+		s.lock.Unlock()
+		refresh.token, refresh.err = token, err
+		close(refresh.done)
+	}()
+	return refresh
+}
+
+// Invalidate 废弃当前访问令牌，下次获取时重新请求，refresh token保留
+func (s *OAuth2TokenSource) Invalidate() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.token == nil {
+		return
+	}
+	s.token = &OAuth2Token{RefreshToken: s.token.RefreshToken}
+}
+
+func (s *OAuth2TokenSource) Authenticate(ctx context.Context, req *http.Request) error {
+	var token, err = s.OAuth2Token(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(headerAuthorization, token.AuthorizationHeader())
+	return nil
+}
+
+// HandleUnauthorized 收到401时，若请求使用的仍是当前令牌则废弃它，然后重发请求
+func (s *OAuth2TokenSource) HandleUnauthorized(ctx context.Context, req *http.Request, rsp *http.Response) bool {
+	s.lock.Lock()
+	if s.token != nil && req.Header.Get(headerAuthorization) == s.token.AuthorizationHeader() {
+		s.token = &OAuth2Token{RefreshToken: s.token.RefreshToken}
+	}
+	s.lock.Unlock()
+	return true
+}
+
+func (cfg *OAuth2Config) refreshTimeout() time.Duration {
+	if cfg.RefreshTimeout <= 0 {
+		return defaultRefreshTimeout
+	}
+	return cfg.RefreshTimeout
+}
+
+func (cfg *OAuth2Config) expiryDelta() time.Duration {
+	if cfg.ExpiryDelta <= 0 {
+		return defaultExpiryDelta
+	}
+	return cfg.ExpiryDelta
+}
+
+// requestToken 请求令牌端点
+func (cfg *OAuth2Config) requestToken(ctx context.Context, params url.Values) (*OAuth2Token, error) {
+	var token = &OAuth2Token{}
+	var err = cfg.postForm(ctx, cfg.TokenURL, params, token)
+	if err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, &OAuth2Error{Code: "invalid_response", Description: "access_token is missing"}
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// postForm 携带客户端凭证向授权服务器提交表单，并将JSON响应解析到out
+func (cfg *OAuth2Config) postForm(ctx context.Context, endpoint string, params url.Values, out interface{}) error {
+	for k, v := range cfg.EndpointParams {
+		params[k] = v
+	}
+	var req = NewRequest("POST", endpoint)
+	if cfg.AuthInParams || cfg.ClientSecret == "" {
+		params.Set("client_id", cfg.ClientID)
+		if cfg.ClientSecret != "" {
+			params.Set("client_secret", cfg.ClientSecret)
+		}
+	} else {
+		req.AddHeader(headerAuthorization, "Basic "+basicCredentials(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret)))
+	}
+	for k, values := range params {
+		for _, v := range values {
+			req.AddFormItem(k, v)
+		}
+	}
+	req.AddHeader("Accept", "application/json")
+	var client = cfg.Client
+	if client == nil {
+		client = DefaultClient
+	}
+	var rsp, err = client.Do(ctx, req, WithRequestNon2xxError(false), WithoutCache())
+	if err != nil {
+		return err
+	}
+	if !isSuccessStatus(rsp.StatusCode()) {
+		var oErr = &OAuth2Error{}
+		if rsp.JSONUnmarshal(oErr) != nil || oErr.Code == "" {
+			return NewHTTPError(rsp)
+		}
+		oErr.StatusCode = rsp.StatusCode()
+		return oErr
+	}
+	return rsp.JSONUnmarshal(out)
+}
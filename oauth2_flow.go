@@ -0,0 +1,292 @@
+package restgo
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	grantTypeDeviceCode   = "urn:ietf:params:oauth:grant-type:device_code"
+	defaultCallbackPath   = "/callback"
+	defaultDevicePoll     = 5 * time.Second
+	deviceSlowDownBackoff = 5 * time.Second
+)
+
+// TokenStore 持久化OAuth2令牌，没有令牌时Load返回fs.ErrNotExist
+type TokenStore interface {
+	Load() (*OAuth2Token, error)
+	Save(token *OAuth2Token) error
+}
+
+// FileTokenStore 以JSON文件保存令牌，文件权限0600
+type FileTokenStore struct {
+	Path string
+}
+
+// NewFileTokenStore 新建文件令牌存储
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{Path: path}
+}
+
+func (s *FileTokenStore) Load() (*OAuth2Token, error) {
+	var data, err = os.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	var token = &OAuth2Token{}
+	err = json.Unmarshal(data, token)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// Save 先写临时文件再rename，避免写入中断时损坏已有令牌
+func (s *FileTokenStore) Save(token *OAuth2Token) error {
+	var data, err = json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	var dir = filepath.Dir(s.Path)
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+	var f *os.File
+	f, err = os.CreateTemp(dir, ".token-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.Path)
+}
+
+// AuthCodeFlow 授权码模式+PKCE（RFC 7636），在本地监听回调地址接收授权码，适用于命令行工具
+type AuthCodeFlow struct {
+	Config OAuth2Config
+	// ListenAddr 回调监听地址，默认127.0.0.1:0（随机端口）
+	ListenAddr string
+	// CallbackPath 回调路径，默认/callback
+	CallbackPath string
+	// AuthParams 授权请求附加的参数，例如prompt
+	AuthParams url.Values
+	// OpenURL 打开授权页面，默认将地址打印到stderr
+	OpenURL func(authURL string) error
+}
+
+// Run 执行授权流程，返回的令牌会保存到Config.Store
+func (f *AuthCodeFlow) Run(ctx context.Context) (*OAuth2Token, error) {
+	var addr = f.ListenAddr
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	var callbackPath = f.CallbackPath
+	if callbackPath == "" {
+		callbackPath = defaultCallbackPath
+	}
+	var listener, err = net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	var redirectURL = "http://" + listener.Addr().String() + callbackPath
+	var state, verifier = randomString(), randomString()
+
+	var result = make(chan url.Values, 1)
+	var mux = http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		var query = r.URL.Query()
+		if query.Get("state") != state {
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			return
+		}
+		select {
+		case result <- query:
+			_, _ = io.WriteString(w, "Authorization completed, you can close this window.")
+		default:
+			http.Error(w, "authorization already completed", http.StatusConflict)
+		}
+	})
+	var server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+
+	var authURL string
+	authURL, err = f.authCodeURL(redirectURL, state, verifier)
+	if err != nil {
+		return nil, err
+	}
+	var open = f.OpenURL
+	if open == nil {
+		open = func(authURL string) error {
+			_, err := fmt.Fprintf(os.Stderr, "Open the following URL in your browser to log in:\n%s\n", authURL)
+			return err
+		}
+	}
+	err = open(authURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var query url.Values
+	select {
+	case query = <-result:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if code := query.Get("error"); code != "" {
+		return nil, &OAuth2Error{Code: code, Description: query.Get("error_description"), URI: query.Get("error_uri")}
+	}
+	var token *OAuth2Token
+	token, err = f.Config.requestToken(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {query.Get("code")},
+		"redirect_uri":  {redirectURL},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token, saveToken(f.Config.Store, token)
+}
+
+func (f *AuthCodeFlow) authCodeURL(redirectURL, state, verifier string) (string, error) {
+	var u, err = url.Parse(f.Config.AuthURL)
+	if err != nil {
+		return "", err
+	}
+	var challenge = sha256.Sum256([]byte(verifier))
+	var q = u.Query()
+	for k, v := range f.AuthParams {
+		q[k] = v
+	}
+	q.Set("response_type", "code")
+	q.Set("client_id", f.Config.ClientID)
+	q.Set("redirect_uri", redirectURL)
+	q.Set("state", state)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	if len(f.Config.Scopes) != 0 {
+		q.Set("scope", strings.Join(f.Config.Scopes, " "))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// DeviceAuthResponse 设备授权响应（RFC 8628 3.2）
+type DeviceAuthResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval,omitempty"`
+}
+
+// DeviceCodeFlow 设备码模式（RFC 8628），用户在其他设备上完成授权，期间轮询令牌端点
+type DeviceCodeFlow struct {
+	Config OAuth2Config
+	// Prompt 向用户展示验证地址和用户码，默认打印到stderr
+	Prompt func(rsp *DeviceAuthResponse) error
+	// Interval 轮询间隔，默认使用服务端返回的间隔，服务端未返回时为5s
+	Interval time.Duration
+}
+
+// Run 执行授权流程，返回的令牌会保存到Config.Store
+func (f *DeviceCodeFlow) Run(ctx context.Context) (*OAuth2Token, error) {
+	var params = url.Values{}
+	if len(f.Config.Scopes) != 0 {
+		params.Set("scope", strings.Join(f.Config.Scopes, " "))
+	}
+	var auth = &DeviceAuthResponse{}
+	var err = f.Config.postForm(ctx, f.Config.DeviceAuthURL, params, auth)
+	if err != nil {
+		return nil, err
+	}
+	if auth.DeviceCode == "" {
+		return nil, &OAuth2Error{Code: "invalid_response", Description: "device_code is missing"}
+	}
+	var prompt = f.Prompt
+	if prompt == nil {
+		prompt = func(rsp *DeviceAuthResponse) error {
+			_, err := fmt.Fprintf(os.Stderr, "Open %s and enter code %s\n", rsp.VerificationURI, rsp.UserCode)
+			return err
+		}
+	}
+	err = prompt(auth)
+	if err != nil {
+		return nil, err
+	}
+	if auth.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(auth.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+	var interval = f.Interval
+	if interval <= 0 {
+		interval = time.Duration(auth.Interval) * time.Second
+	}
+	if interval <= 0 {
+		interval = defaultDevicePoll
+	}
+	for {
+		err = sleepContext(ctx, interval)
+		if err != nil {
+			return nil, err
+		}
+		var token *OAuth2Token
+		token, err = f.Config.requestToken(ctx, url.Values{
+			"grant_type":  {grantTypeDeviceCode},
+			"device_code": {auth.DeviceCode},
+		})
+		if err == nil {
+			return token, saveToken(f.Config.Store, token)
+		}
+		var oErr *OAuth2Error
+		if !errors.As(err, &oErr) {
+			return nil, err
+		}
+		switch oErr.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += deviceSlowDownBackoff
+		default:
+			return nil, err
+		}
+	}
+}
+
+func saveToken(store TokenStore, token *OAuth2Token) error {
+	if store == nil {
+		return nil
+	}
+	return store.Save(token)
+}
+
+// randomString 32字节随机数的base64url编码，用于state和PKCE verifier
+func randomString() string {
+	var b = make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
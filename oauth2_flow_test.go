@@ -0,0 +1,119 @@
+package restgo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFakeAuthServer 模拟授权服务器，支持授权码+PKCE和设备码模式
+func newFakeAuthServer(t *testing.T) *httptest.Server {
+	var challenge string
+	var polls int32
+	var mux = http.NewServeMux()
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		var q = r.URL.Query()
+		if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != "cli" {
+			t.Errorf("unexpected authorize request %s", r.URL)
+		}
+		challenge = q.Get("code_challenge")
+		var redirect, _ = url.Parse(q.Get("redirect_uri"))
+		redirect.RawQuery = url.Values{"code": {"c1"}, "state": {q.Get("state")}}.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"device_code":"d1","user_code":"ABCD","verification_uri":"http://example.com/device","expires_in":60}`))
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("client_id") != "cli" {
+			t.Errorf("expect public client id, got %v", r.Form)
+		}
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			var sum = sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if r.Form.Get("code") != "c1" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"code-token","refresh_token":"r1","expires_in":3600}`))
+		case grantTypeDeviceCode:
+			if atomic.AddInt32(&polls, 1) < 3 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"authorization_pending"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"device-token","expires_in":3600}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"unsupported_grant_type"}`))
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestAuthCodeFlow(t *testing.T) {
+	var srv = newFakeAuthServer(t)
+	defer srv.Close()
+	var store = NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	var cfg = OAuth2Config{ClientID: "cli", AuthURL: srv.URL + "/authorize", TokenURL: srv.URL + "/token", Store: store}
+	var flow = &AuthCodeFlow{
+		Config: cfg,
+		// browser: follow the redirect to the local callback listener
+		OpenURL: func(authURL string) error {
+			go func() {
+				var rsp, err = http.Get(authURL)
+				if err == nil {
+					_ = rsp.Body.Close()
+				}
+			}()
+			return nil
+		},
+	}
+	var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var token, err = flow.Run(ctx)
+	if err != nil || token.AccessToken != "code-token" {
+		t.Fatalf("unexpected token %+v %v", token, err)
+	}
+
+	// a new process picks up the persisted token
+	var src = NewRefreshTokenSource(cfg, nil)
+	var access string
+	access, err = src.Token(ctx)
+	if err != nil || access != "code-token" {
+		t.Errorf("expect persisted token, got %s %v", access, err)
+	}
+}
+
+func TestDeviceCodeFlow(t *testing.T) {
+	var srv = newFakeAuthServer(t)
+	defer srv.Close()
+	var store = NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	var userCode string
+	var flow = &DeviceCodeFlow{
+		Config:   OAuth2Config{ClientID: "cli", DeviceAuthURL: srv.URL + "/device", TokenURL: srv.URL + "/token", Store: store},
+		Interval: 10 * time.Millisecond,
+		Prompt: func(rsp *DeviceAuthResponse) error {
+			userCode = rsp.UserCode
+			return nil
+		},
+	}
+	var token, err = flow.Run(context.Background())
+	if err != nil || token.AccessToken != "device-token" || userCode != "ABCD" {
+		t.Fatalf("unexpected token %+v %s %v", token, userCode, err)
+	}
+	var stored *OAuth2Token
+	stored, err = store.Load()
+	if err != nil || stored.AccessToken != "device-token" {
+		t.Errorf("expect stored token, got %+v %v", stored, err)
+	}
+}
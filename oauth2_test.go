@@ -0,0 +1,156 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTokenServer(t *testing.T, issued *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		var id, secret, _ = r.BasicAuth()
+		if id != "id" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		if r.Form.Get("grant_type") == "refresh_token" && r.Form.Get("refresh_token") != "r0" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+		var n = atomic.AddInt32(issued, 1)
+		w.Header().Set(headerContentType, "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"t%d","token_type":"bearer","expires_in":3600}`, n)
+	}))
+}
+
+func TestOAuth2TokenSource_ClientCredentials(t *testing.T) {
+	var issued int32
+	var tokenSrv = newTokenServer(t, &issued)
+	defer tokenSrv.Close()
+	var src = NewClientCredentialsSource(OAuth2Config{ClientID: "id", ClientSecret: "secret", TokenURL: tokenSrv.URL})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var token, err = src.Token(context.Background())
+			if err != nil || token != "t1" {
+				t.Errorf("expect t1, got %s %v", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if issued != 1 {
+		t.Errorf("expect 1 token request, got %d", issued)
+	}
+
+	// api server rejects the first token, request is replayed once with a new token
+	var apiSrv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body = make([]byte, 4)
+		_, _ = r.Body.Read(body)
+		if r.Header.Get(headerAuthorization) != "Bearer t2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer apiSrv.Close()
+	var c = New(WithBaseURL(apiSrv.URL), WithAuthenticator(src))
+	var rsp, err = c.Post(context.Background(), "/", NewBodyParam("text/plain", NewBytesBody([]byte("body"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	data, err = rsp.Data()
+	if err != nil || rsp.StatusCode() != http.StatusOK || string(data) != "body" {
+		t.Errorf("expect replayed request, got %d %q %v", rsp.StatusCode(), data, err)
+	}
+}
+
+func TestOAuth2TokenSource_RefreshToken(t *testing.T) {
+	var issued int32
+	var tokenSrv = newTokenServer(t, &issued)
+	defer tokenSrv.Close()
+	var cfg = OAuth2Config{ClientID: "id", ClientSecret: "secret", TokenURL: tokenSrv.URL}
+	var src = NewRefreshTokenSource(cfg, &OAuth2Token{RefreshToken: "r0"})
+	var token, err = src.OAuth2Token(context.Background())
+	if err != nil || token.AccessToken != "t1" || token.RefreshToken != "r0" {
+		t.Fatalf("unexpected token %+v %v", token, err)
+	}
+
+	src = NewRefreshTokenSource(cfg, &OAuth2Token{RefreshToken: "bad"})
+	_, err = src.Token(context.Background())
+	var oErr *OAuth2Error
+	if !errors.As(err, &oErr) || oErr.Code != "invalid_grant" || oErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expect invalid_grant, got %v", err)
+	}
+}
+
+func TestOAuth2TokenSource_RefreshDetached(t *testing.T) {
+	var issued int32
+	var release = make(chan struct{})
+	var tokenSrv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		var n = atomic.AddInt32(&issued, 1)
+		w.Header().Set(headerContentType, "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"t%d","expires_in":3600}`, n)
+	}))
+	defer tokenSrv.Close()
+	var src = NewClientCredentialsSource(OAuth2Config{ClientID: "id", ClientSecret: "secret", TokenURL: tokenSrv.URL})
+
+	// the first caller starts the refresh and gives up, the waiter still gets the token
+	var ctx, cancel = context.WithCancel(context.Background())
+	var leaderErr = make(chan error, 1)
+	go func() {
+		var _, err = src.Token(ctx)
+		leaderErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	var waiter = make(chan string, 1)
+	go func() {
+		var token, err = src.Token(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		waiter <- token
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expect canceled, got %v", err)
+	}
+	close(release)
+	if token := <-waiter; token != "t1" {
+		t.Errorf("expect t1, got %s", token)
+	}
+	if issued != 1 {
+		t.Errorf("expect 1 token request, got %d", issued)
+	}
+
+	// the refresh is bounded by its own timeout
+	var stop = make(chan struct{})
+	var slowSrv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stop
+	}))
+	defer slowSrv.Close()
+	defer close(stop)
+	src = NewClientCredentialsSource(OAuth2Config{
+		ClientID: "id", ClientSecret: "secret", TokenURL: slowSrv.URL, RefreshTimeout: 50 * time.Millisecond,
+	})
+	if _, err := src.Token(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expect deadline exceeded, got %v", err)
+	}
+}
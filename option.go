@@ -6,22 +6,59 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"path"
 	"time"
 )
 
 type option struct {
-	baseURL       *url.URL
-	globalHeader  http.Header
-	transport     http.RoundTripper
+	baseURL      *url.URL
+	globalHeader http.Header
+	transport    http.RoundTripper
+	// transportOpts applied to a copy of transport
+	transportOpts []TransportOption
+	// built transport built from transport and transportOpts, shared by derived clients
+	built         http.RoundTripper
 	jar           http.CookieJar
 	timeout       time.Duration
 	checkRedirect func(req *http.Request, via []*http.Request) error
 	beforeHooks   []BeforeHookFunc
 	afterHooks    []AfterHookFunc
+	non2xxError   bool
+	retry         RetryPolicy
+	retryHooks    []RetryHookFunc
+	middlewares   []Middleware
+
+	auth                 Authenticator
+	authID               uint64
+	signer               Signer
+	verifier             ResponseVerifier
+	transformer          BodyTransformer
+	disableDecompression bool
+	compression          string
+	compressThreshold    int64
+	// callBuiltins built-in middlewares, run once for every call inside of user middlewares
+	callBuiltins []Middleware
+	// builtins built-in middlewares, run for every attempt inside of retry
+	builtins []Middleware
 }
 
 type OptionFn func(opt *option)
 
+// clone 复制配置，transport和jar共享
+func (o *option) clone() *option {
+	var n = *o
+	n.baseURL = CloneURL(o.baseURL)
+	n.globalHeader = o.globalHeader.Clone()
+	n.beforeHooks = append([]BeforeHookFunc(nil), o.beforeHooks...)
+	n.afterHooks = append([]AfterHookFunc(nil), o.afterHooks...)
+	n.retryHooks = append([]RetryHookFunc(nil), o.retryHooks...)
+	n.middlewares = append([]Middleware(nil), o.middlewares...)
+	n.callBuiltins = append([]Middleware(nil), o.callBuiltins...)
+	n.builtins = append([]Middleware(nil), o.builtins...)
+	n.transportOpts = append([]TransportOption(nil), o.transportOpts...)
+	return &n
+}
+
 func WithBaseURL(baseURL string) OptionFn {
 	return func(opt *option) {
 		opt.baseURL, _ = url.ParseRequestURI(baseURL)
@@ -34,24 +71,51 @@ func WithGlobalHeader(header http.Header) OptionFn {
 	}
 }
 
+// WithBasePath 在base URL的路径后追加路径
+func WithBasePath(segments ...string) OptionFn {
+	return func(opt *option) {
+		if opt.baseURL == nil {
+			opt.baseURL = &url.URL{}
+		}
+		opt.baseURL.Path = path.Join(append([]string{opt.baseURL.Path}, segments...)...)
+		opt.baseURL.RawPath = ""
+	}
+}
+
+// WithHeader 设置一个全局header，不影响已设置的其他全局header
+func WithHeader(name, value string) OptionFn {
+	return func(opt *option) {
+		opt.globalHeader = opt.globalHeader.Clone()
+		if opt.globalHeader == nil {
+			opt.globalHeader = http.Header{}
+		}
+		opt.globalHeader.Set(name, value)
+	}
+}
+
+// WithTransport 设置基础transport，WithTransportOptions设置的选项作用于它的副本
+// 同时设置了WithTransportOptions时transport必须是*http.Transport
 func WithTransport(transport http.RoundTripper) OptionFn {
 	return func(opt *option) {
 		opt.transport = transport
+		opt.built = nil
 	}
 }
 
-func WithCert(certPool *x509.CertPool, cert tls.Certificate) OptionFn {
+// WithTransportOptions 配置TLS、代理、超时、连接池等，与其他选项的顺序无关
+// 派生客户端设置该选项时会创建新的transport，不再与父客户端共享连接池
+func WithTransportOptions(opts ...TransportOption) OptionFn {
 	return func(opt *option) {
-		opt.transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				RootCAs:      certPool,
-				Certificates: []tls.Certificate{cert},
-				MinVersion:   tls.VersionTLS12,
-			},
-		}
+		opt.transportOpts = append(opt.transportOpts, opts...)
+		opt.built = nil
 	}
 }
 
+// WithCert 设置CA证书池和客户端证书，等同于WithTransportOptions(TLSRootCAPool(certPool), TLSClientCert(cert))
+func WithCert(certPool *x509.CertPool, cert tls.Certificate) OptionFn {
+	return WithTransportOptions(TLSRootCAPool(certPool), TLSClientCert(cert))
+}
+
 func WithJar(jar *cookiejar.Jar) OptionFn {
 	return func(opt *option) {
 		opt.jar = jar
@@ -79,7 +143,7 @@ func WithCheckRedirect(checkRedirect func(req *http.Request, via []*http.Request
 	}
 }
 
-// WithBeforeHook 挂载请求前的钩子函数
+// WithBeforeHook 挂载请求前的钩子函数，钩子在构造http请求之前运行，早于所有中间件
 func WithBeforeHook(hook BeforeHookFunc) OptionFn {
 	return func(opt *option) {
 		opt.beforeHooks = append(opt.beforeHooks, hook)
@@ -87,8 +151,129 @@ func WithBeforeHook(hook BeforeHookFunc) OptionFn {
 }
 
 // WithAfterHook 挂载请求后的钩子函数
+// 钩子在响应验证及body解码之后运行，与调用方读取到相同的body
 func WithAfterHook(hook AfterHookFunc) OptionFn {
 	return func(opt *option) {
 		opt.afterHooks = append(opt.afterHooks, hook)
 	}
 }
+
+// WithNon2xxError 开启后非2xx响应将作为*HTTPError返回，可被IRequest.WithNon2xxError覆盖
+func WithNon2xxError(enable bool) OptionFn {
+	return func(opt *option) {
+		opt.non2xxError = enable
+	}
+}
+
+// WithRetry 设置重试策略，参见DefaultRetryPolicy
+func WithRetry(policy RetryPolicy) OptionFn {
+	return func(opt *option) {
+		opt.retry = policy
+	}
+}
+
+// WithRetryHook 挂载重试钩子函数
+func WithRetryHook(hook RetryHookFunc) OptionFn {
+	return func(opt *option) {
+		opt.retryHooks = append(opt.retryHooks, hook)
+	}
+}
+
+// WithMiddleware 挂载中间件，先挂载的中间件位于外层
+func WithMiddleware(mws ...Middleware) OptionFn {
+	return func(opt *option) {
+		opt.middlewares = append(opt.middlewares, mws...)
+	}
+}
+
+// WithCircuitBreaker 开启熔断
+func WithCircuitBreaker(breaker *CircuitBreaker) OptionFn {
+	return func(opt *option) {
+		opt.builtins = append(opt.builtins, breaker.Middleware())
+	}
+}
+
+// WithRateLimiter 开启客户端限速，每次请求（含重试）发送前等待令牌
+func WithRateLimiter(limiter *RateLimiter) OptionFn {
+	return func(opt *option) {
+		opt.builtins = append(opt.builtins, limiter.Middleware())
+	}
+}
+
+// WithThrottler 开启自适应限流，根据响应中的限流头降速或暂停对同一host的请求
+func WithThrottler(throttler *Throttler) OptionFn {
+	return func(opt *option) {
+		opt.builtins = append(opt.builtins, throttler.Middleware())
+	}
+}
+
+// WithBulkhead 限制客户端及每个host的并发请求数
+func WithBulkhead(bulkhead *Bulkhead) OptionFn {
+	return func(opt *option) {
+		opt.builtins = append(opt.builtins, bulkhead.Middleware())
+	}
+}
+
+// WithCoalescing 合并相同的进行中的GET/HEAD请求（method + URL + headers指定的header），只发送一次
+// 合并后响应body会被完整读入内存，每个调用方得到独立的响应
+func WithCoalescing(headers ...string) OptionFn {
+	return func(opt *option) {
+		opt.callBuiltins = append(opt.callBuiltins, newCoalescer(headers).middleware)
+	}
+}
+
+// WithCache 开启HTTP缓存（RFC 9111），缓存GET响应并自动使用ETag/Last-Modified验证
+// 缓存由派生客户端共享，带认证的请求仅在响应允许共享（public、s-maxage或must-revalidate）时存储
+func WithCache(store CacheStore) OptionFn {
+	return func(opt *option) {
+		opt.callBuiltins = append(opt.callBuiltins, newHTTPCache(store).middleware)
+	}
+}
+
+// WithDecompression 是否按Content-Encoding自动解压响应，默认开启
+// 内置gzip、deflate、br和zstd，其他编码通过RegisterDecoder注册
+func WithDecompression(enable bool) OptionFn {
+	return func(opt *option) {
+		opt.disableDecompression = !enable
+	}
+}
+
+// WithRequestCompression 使用encoding压缩长度不小于threshold（或长度未知）的请求body
+// 内置gzip、deflate、br和zstd，其他编码通过RegisterEncoder注册
+func WithRequestCompression(encoding string, threshold int64) OptionFn {
+	return func(opt *option) {
+		opt.compression = encoding
+		opt.compressThreshold = threshold
+	}
+}
+
+// WithSigner 设置请求签名器，每次发送请求（含重试）前在认证之后调用
+func WithSigner(signer Signer) OptionFn {
+	return func(opt *option) {
+		opt.signer = signer
+	}
+}
+
+// WithResponseVerifier 设置响应验证器，Client.Do读取响应body后验证，验证失败时返回VerificationError
+// 来自缓存的响应同样会验证，开启缓存时时间戳检查需允许缓存时长内的偏差
+func WithResponseVerifier(verifier ResponseVerifier) OptionFn {
+	return func(opt *option) {
+		opt.verifier = verifier
+	}
+}
+
+// WithBodyTransformer 设置body转换器，对请求body编码（例如加密）并在Response.Data返回前解码响应body
+// 请求body会被完整读入内存后编码
+func WithBodyTransformer(transformer BodyTransformer) OptionFn {
+	return func(opt *option) {
+		opt.transformer = transformer
+	}
+}
+
+// WithAuthenticator 设置认证器，每次发送请求（含重试）前调用
+func WithAuthenticator(auth Authenticator) OptionFn {
+	return func(opt *option) {
+		opt.auth = auth
+		opt.authID = newAuthID()
+	}
+}
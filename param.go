@@ -91,9 +91,14 @@ func NewFormDataParam(name, value string) *FormDataParam {
 
 // BodyParam 将参数作为HTTP Body携带，具体序列化方式通过参数内容类型而定
 // 同一个request有且仅有一个BodyParam
+// 设置GetBody或Value实现io.Seeker时body可重复发送（重定向、重试），否则只能发送一次
 type BodyParam struct {
 	ContentType string
 	Value       io.Reader
+	// GetBody 优先于Value使用，每次调用返回新的body
+	GetBody BodyFunc
+	// ContentLength GetBody返回的body长度，小于等于0表示未知
+	ContentLength int64
 }
 
 func NewBodyParam(contentType string, value io.Reader) *BodyParam {
@@ -112,10 +117,7 @@ func NewJSONBody(obj interface{}) (*BodyParam, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &BodyParam{
-		ContentType: "application/json; charset=utf-8",
-		Value:       bytes.NewBuffer(buff),
-	}, nil
+	return newBytesBodyParam("application/json; charset=utf-8", buff), nil
 }
 
 func NewXMLBody(obj interface{}) (*BodyParam, error) {
@@ -123,10 +125,22 @@ func NewXMLBody(obj interface{}) (*BodyParam, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newBytesBodyParam("application/xml; charset=utf-8", buff), nil
+}
+
+// NewBodyFuncParam 由BodyFunc构造可重复发送的body，size小于等于0表示长度未知
+func NewBodyFuncParam(contentType string, size int64, getBody BodyFunc) *BodyParam {
+	return &BodyParam{ContentType: contentType, GetBody: getBody, ContentLength: size}
+}
+
+func newBytesBodyParam(contentType string, buff []byte) *BodyParam {
+	var body = NewBytesBody(buff)
 	return &BodyParam{
-		ContentType: "application/xml; charset=utf-8",
-		Value:       bytes.NewBuffer(buff),
-	}, nil
+		ContentType:   contentType,
+		Value:         bytes.NewBuffer(buff),
+		GetBody:       body.GetBody,
+		ContentLength: int64(len(buff)),
+	}
 }
 
 func (p BodyParam) ParamName() string {
@@ -0,0 +1,152 @@
+package restgo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Limit 令牌桶限速配置
+type Limit struct {
+	// Rate 每秒生成的令牌数，小于等于0表示不限速
+	Rate float64
+	// Burst 桶容量，小于1时按1处理
+	Burst int
+}
+
+// TokenBucket 令牌桶
+type TokenBucket struct {
+	lock   sync.Mutex
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+func NewTokenBucket(limit Limit) *TokenBucket {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &TokenBucket{limit: limit, tokens: float64(limit.Burst), last: time.Now()}
+}
+
+// Wait 等待获取一个令牌，ctx结束时返回ctx的错误并归还令牌
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if b.limit.Rate <= 0 {
+		return nil
+	}
+	var wait = b.reserve()
+	if wait <= 0 {
+		return nil
+	}
+	var err = sleepContext(ctx, wait)
+	if err != nil {
+		b.cancel()
+	}
+	return err
+}
+
+// reserve 预留一个令牌，返回需要等待的时间
+func (b *TokenBucket) reserve() time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	var now = time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.limit.Rate
+	if b.tokens > float64(b.limit.Burst) {
+		b.tokens = float64(b.limit.Burst)
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.limit.Rate * float64(time.Second))
+}
+
+func (b *TokenBucket) cancel() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.tokens++
+}
+
+// RateLimiterConfig 限速配置，各维度同时生效
+type RateLimiterConfig struct {
+	// Global 整个客户端的限速
+	Global Limit
+	// PerHost 每个host的限速，Hosts中配置的host除外
+	PerHost Limit
+	// Hosts 指定host的限速
+	Hosts map[string]Limit
+	// Routes 指定路由的限速，路由通过WithRateLimitKey指定
+	Routes map[string]Limit
+}
+
+// RateLimiter 客户端限速器，依次等待路由、host、全局令牌
+type RateLimiter struct {
+	cfg    RateLimiterConfig
+	global *TokenBucket
+	lock   sync.Mutex
+	hosts  map[string]*TokenBucket
+	routes map[string]*TokenBucket
+}
+
+func NewRateLimiter(cfg RateLimiterConfig) *RateLimiter {
+	return &RateLimiter{
+		cfg:    cfg,
+		global: NewTokenBucket(cfg.Global),
+		hosts:  make(map[string]*TokenBucket),
+		routes: make(map[string]*TokenBucket),
+	}
+}
+
+// Wait 等待请求被允许发送
+func (l *RateLimiter) Wait(ctx context.Context, host, route string) error {
+	if route != "" {
+		if limit, ok := l.cfg.Routes[route]; ok {
+			var err = l.bucket(l.routes, route, limit).Wait(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	var limit, ok = l.cfg.Hosts[host]
+	if !ok {
+		limit = l.cfg.PerHost
+	}
+	if limit.Rate > 0 {
+		var err = l.bucket(l.hosts, host, limit).Wait(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return l.global.Wait(ctx)
+}
+
+// Middleware 限速中间件
+func (l *RateLimiter) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			var route string
+			if opt := getCallState(ctx).opt; opt != nil {
+				route = opt.rateLimitKey
+			}
+			var err = l.Wait(ctx, req.URL.Host, route)
+			if err != nil {
+				closeRequestBody(req)
+				return nil, err
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+func (l *RateLimiter) bucket(buckets map[string]*TokenBucket, key string, limit Limit) *TokenBucket {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var b, ok = buckets[key]
+	if !ok {
+		b = NewTokenBucket(limit)
+		buckets[key] = b
+	}
+	return b
+}
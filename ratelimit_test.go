@@ -0,0 +1,33 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	var limiter = NewRateLimiter(RateLimiterConfig{
+		PerHost: Limit{Rate: 100, Burst: 2},
+		Routes:  map[string]Limit{"slow": {Rate: 1, Burst: 1}},
+	})
+	var ctx = context.Background()
+	var start = time.Now()
+	for i := 0; i < 4; i++ {
+		if err := limiter.Wait(ctx, "a.com", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cost := time.Since(start); cost < 15*time.Millisecond {
+		t.Errorf("expect rate limited, cost %v", cost)
+	}
+	if err := limiter.Wait(ctx, "b.com", "slow"); err != nil {
+		t.Fatal(err)
+	}
+	var timeoutCtx, cancel = context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(timeoutCtx, "b.com", "slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expect deadline exceeded, got %v", err)
+	}
+}
@@ -1,7 +1,7 @@
 package restgo
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -26,14 +26,43 @@ type IRequest interface {
 	AddFileBytes(fieldName, fileName string, bytes []byte) IRequest
 	AddFilePath(fieldName, filePath string) IRequest
 	SetBody(contentType string, value io.Reader) IRequest
+	// SetBodyFunc set a replayable body, size <= 0 means unknown
+	SetBodyFunc(contentType string, size int64, getBody BodyFunc) IRequest
 	SetJSONBody(obj interface{}) IRequest
 	SetXMLBody(obj interface{}) IRequest
 	WithContentType(contentType string) IRequest
+	// WithNon2xxError override client option WithNon2xxError for this request
+	WithNon2xxError(enable bool) IRequest
+	// WithOptions add options which override client options for this request
+	WithOptions(opts ...RequestOption) IRequest
 
 	MakeURL(baseURL *url.URL) (string, error)
 	GetMethod() string
+	GetOptions() []RequestOption
 	MakeRequestBody() (io.Reader, error)
 	WrapperHTTPRequest(req *http.Request)
+	// Validate return accumulated builder errors and parameter conflicts
+	// the request must not be sent if it returns non-nil error
+	Validate() error
+}
+
+var (
+	// ErrParamConflict 参数冲突，例如同时设置了BodyParam和FormItems/Files
+	ErrParamConflict = errors.New("restgo: conflicting request params")
+)
+
+// BuildError 构建请求时的错误，Op为出错的构建方法
+type BuildError struct {
+	Op  string
+	Err error
+}
+
+func (e *BuildError) Error() string {
+	return "restgo: " + e.Op + ": " + e.Err.Error()
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
 }
 
 type Request struct {
@@ -48,8 +77,12 @@ type Request struct {
 	Files       []*FileParam
 	Body        *BodyParam
 	ContentType string
+	// Options 覆盖客户端配置
+	Options []RequestOption
 
-	Err error
+	// Err joined errors of all failed builder calls
+	Err  error
+	errs []error
 }
 
 func NewRequest(method, resource string) *Request {
@@ -129,10 +162,15 @@ func (r *Request) SetBody(contentType string, value io.Reader) IRequest {
 	return r
 }
 
+func (r *Request) SetBodyFunc(contentType string, size int64, getBody BodyFunc) IRequest {
+	r.Body = NewBodyFuncParam(contentType, size, getBody)
+	return r
+}
+
 func (r *Request) SetJSONBody(obj interface{}) IRequest {
 	var body, err = NewJSONBody(obj)
 	if err != nil {
-		r.Err = err
+		r.addErr("SetJSONBody", err)
 		return r
 	}
 	r.Body = body
@@ -142,7 +180,7 @@ func (r *Request) SetJSONBody(obj interface{}) IRequest {
 func (r *Request) SetXMLBody(obj interface{}) IRequest {
 	var body, err = NewXMLBody(obj)
 	if err != nil {
-		r.Err = err
+		r.addErr("SetXMLBody", err)
 		return r
 	}
 	r.Body = body
@@ -157,7 +195,7 @@ func (r *Request) AddFileBytes(fieldName, fileName string, bytes []byte) IReques
 func (r *Request) AddFilePath(fieldName, filePath string) IRequest {
 	var p, err = NewPathFileParam(fieldName, filePath)
 	if err != nil {
-		r.Err = err
+		r.addErr("AddFilePath", err)
 		return r
 	}
 	r.Files = append(r.Files, p)
@@ -169,6 +207,15 @@ func (r *Request) WithContentType(contentType string) IRequest {
 	return r
 }
 
+func (r *Request) WithNon2xxError(enable bool) IRequest {
+	return r.WithOptions(WithRequestNon2xxError(enable))
+}
+
+func (r *Request) WithOptions(opts ...RequestOption) IRequest {
+	r.Options = append(r.Options, opts...)
+	return r
+}
+
 func (r *Request) MakeURL(baseURL *url.URL) (string, error) {
 	if strings.HasPrefix(r.Resource, "http://") ||
 		strings.HasPrefix(r.Resource, "https://") {
@@ -209,15 +256,20 @@ func (r *Request) GetMethod() string {
 	return r.Method
 }
 
+func (r *Request) GetOptions() []RequestOption {
+	return r.Options
+}
+
 func (r *Request) MakeRequestBody() (io.Reader, error) {
-	if r.Method == "GET" {
+	switch r.GetMethod() {
+	case "GET", "HEAD":
 		return nil, nil
 	}
 	if r.Body != nil {
 		if r.Body.ContentType != "" {
 			r.ContentType = r.Body.ContentType
 		}
-		return r.Body.Value, nil
+		return makeReplayable(r.Body)
 	}
 	if len(r.Files) == 0 {
 		return r.makeFormDataBody(), nil
@@ -238,37 +290,113 @@ func (r *Request) WrapperHTTPRequest(req *http.Request) {
 	}
 }
 
+func (r *Request) Validate() error {
+	var errs = make([]error, 0, len(r.errs)+1)
+	if len(r.errs) != 0 {
+		errs = append(errs, r.errs...)
+	} else if r.Err != nil {
+		// Err was assigned directly
+		errs = append(errs, r.Err)
+	}
+	if r.Body != nil && (len(r.FormItems) != 0 || len(r.Files) != 0) {
+		errs = append(errs, &BuildError{
+			Op:  "BodyParam",
+			Err: fmt.Errorf("%w: body can not be combined with form items or files", ErrParamConflict),
+		})
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Request) addErr(op string, err error) {
+	r.errs = append(r.errs, &BuildError{Op: op, Err: err})
+	r.Err = errors.Join(r.errs...)
+}
+
 func (r *Request) makeFormDataBody() io.Reader {
 	var values = url.Values{}
 	for _, c := range r.FormItems {
 		values.Add(c.Name, c.Value)
 	}
 	r.ContentType = "application/x-www-form-urlencoded"
-	return strings.NewReader(values.Encode())
+	return NewBytesBody([]byte(values.Encode()))
 }
 
+// makeMultipartBody 构造multipart body，发送请求时通过pipe流式写入，不会将文件读入内存
+// 所有文件长度已知时预先计算Content-Length
 func (r *Request) makeMultipartBody() (io.Reader, error) {
-	var body = new(bytes.Buffer)
-	var writer = multipart.NewWriter(body)
-	defer writer.Close()
-	var err error
-	for _, f := range r.FormItems {
+	var boundary = multipart.NewWriter(io.Discard).Boundary()
+	var size, err = r.multipartSize(boundary)
+	if err != nil {
+		return nil, err
+	}
+	var writer = multipart.NewWriter(io.Discard)
+	err = writer.SetBoundary(boundary)
+	if err != nil {
+		return nil, err
+	}
+	r.ContentType = writer.FormDataContentType()
+	var formItems, files = r.FormItems, r.Files
+	return NewReplayableBody(size, func() (io.ReadCloser, error) {
+		var pr, pw = io.Pipe()
+		go func() {
+			pw.CloseWithError(writeMultipart(pw, boundary, formItems, files, true))
+		}()
+		return pr, nil
+	}), nil
+}
+
+// multipartSize 计算multipart body长度，存在长度未知的文件时返回-1
+func (r *Request) multipartSize(boundary string) (int64, error) {
+	var counter = &countWriter{}
+	var size int64
+	for _, f := range r.Files {
+		if f.ContentLength <= 0 {
+			return -1, nil
+		}
+		size += f.ContentLength
+	}
+	var err = writeMultipart(counter, boundary, r.FormItems, r.Files, false)
+	if err != nil {
+		return 0, err
+	}
+	return size + counter.n, nil
+}
+
+// writeMultipart 写入multipart body，withContent为false时不写入文件内容
+func writeMultipart(w io.Writer, boundary string, formItems []*FormDataParam, files []*FileParam, withContent bool) error {
+	var writer = multipart.NewWriter(w)
+	var err = writer.SetBoundary(boundary)
+	if err != nil {
+		return err
+	}
+	for _, f := range formItems {
 		err = writer.WriteField(f.Name, f.Value)
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
-	for _, f := range r.Files {
+	for _, f := range files {
 		var fileWriter io.Writer
 		fileWriter, err = writer.CreateFormFile(f.Name, f.FileName)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if !withContent {
+			continue
 		}
 		err = f.FileWriterFunc(fileWriter)
 		if err != nil {
-			return nil, err
+			return &BuildError{Op: "FileWriterFunc(" + f.FileName + ")", Err: err}
 		}
 	}
-	r.ContentType = writer.FormDataContentType()
-	return body, nil
+	return writer.Close()
+}
+
+type countWriter struct {
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
 }
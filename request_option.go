@@ -0,0 +1,182 @@
+package restgo
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// requestOption 单次请求生效的配置，初始值来自Client
+type requestOption struct {
+	baseURL       *url.URL
+	globalHeader  http.Header
+	headerCopied  bool
+	timeout       time.Duration
+	checkRedirect func(req *http.Request, via []*http.Request) error
+	customClient  bool
+	disableHooks  bool
+	retry         RetryPolicy
+	non2xxError   bool
+	auth          Authenticator
+	authID        uint64
+	signer        Signer
+	verifier      ResponseVerifier
+	transformer   BodyTransformer
+	circuitKey    string
+	rateLimitKey  string
+
+	disableCoalescing bool
+	disableCache      bool
+}
+
+// RequestOption 单次请求的配置，可通过IRequest.WithOptions或Client.Do传入，覆盖Client的配置
+type RequestOption func(opt *requestOption)
+
+func (o *requestOption) header() http.Header {
+	if !o.headerCopied {
+		o.globalHeader = o.globalHeader.Clone()
+		if o.globalHeader == nil {
+			o.globalHeader = http.Header{}
+		}
+		o.headerCopied = true
+	}
+	return o.globalHeader
+}
+
+// WithRequestTimeout 覆盖WithTimeout，0表示不超时
+func WithRequestTimeout(timeout time.Duration) RequestOption {
+	return func(opt *requestOption) {
+		opt.timeout = timeout
+		opt.customClient = true
+	}
+}
+
+// WithRequestBaseURL 覆盖WithBaseURL
+func WithRequestBaseURL(baseURL string) RequestOption {
+	return func(opt *requestOption) {
+		opt.baseURL, _ = url.ParseRequestURI(baseURL)
+	}
+}
+
+// WithRequestHeader 覆盖WithGlobalHeader中同名的header
+func WithRequestHeader(name, value string) RequestOption {
+	return func(opt *requestOption) {
+		opt.header().Set(name, value)
+	}
+}
+
+// WithoutGlobalHeader 不发送WithGlobalHeader中指定名称的header，未指定名称时不发送所有全局header
+func WithoutGlobalHeader(names ...string) RequestOption {
+	return func(opt *requestOption) {
+		if len(names) == 0 {
+			opt.globalHeader = nil
+			opt.headerCopied = true
+			return
+		}
+		var header = opt.header()
+		for _, name := range names {
+			header.Del(name)
+		}
+	}
+}
+
+// WithoutHooks 不运行请求前、请求后以及重试钩子函数
+func WithoutHooks() RequestOption {
+	return func(opt *requestOption) {
+		opt.disableHooks = true
+	}
+}
+
+// WithRequestCheckRedirect 覆盖WithCheckRedirect
+func WithRequestCheckRedirect(checkRedirect func(req *http.Request, via []*http.Request) error) RequestOption {
+	return func(opt *requestOption) {
+		opt.checkRedirect = checkRedirect
+		opt.customClient = true
+	}
+}
+
+// WithRequestRetry 覆盖WithRetry，nil表示不重试
+func WithRequestRetry(policy RetryPolicy) RequestOption {
+	return func(opt *requestOption) {
+		opt.retry = policy
+	}
+}
+
+// WithRequestNon2xxError 覆盖WithNon2xxError
+func WithRequestNon2xxError(enable bool) RequestOption {
+	return func(opt *requestOption) {
+		opt.non2xxError = enable
+	}
+}
+
+// WithCircuitBreakerKey 指定熔断的名称，代替默认的host
+func WithCircuitBreakerKey(key string) RequestOption {
+	return func(opt *requestOption) {
+		opt.circuitKey = key
+	}
+}
+
+// WithRateLimitKey 指定限速的路由，对应RateLimiterConfig.Routes
+func WithRateLimitKey(key string) RequestOption {
+	return func(opt *requestOption) {
+		opt.rateLimitKey = key
+	}
+}
+
+// WithoutCoalescing 不与其他请求合并
+func WithoutCoalescing() RequestOption {
+	return func(opt *requestOption) {
+		opt.disableCoalescing = true
+	}
+}
+
+// WithoutCache 不使用也不更新HTTP缓存
+func WithoutCache() RequestOption {
+	return func(opt *requestOption) {
+		opt.disableCache = true
+	}
+}
+
+// WithRequestAuthenticator 使用auth代替WithAuthenticator设置的认证器
+func WithRequestAuthenticator(auth Authenticator) RequestOption {
+	return func(opt *requestOption) {
+		opt.auth = auth
+		opt.authID = newAuthID()
+	}
+}
+
+// WithoutAuth 不进行认证
+func WithoutAuth() RequestOption {
+	return func(opt *requestOption) {
+		opt.auth = nil
+		opt.authID = 0
+	}
+}
+
+// WithRequestSigner 使用signer代替WithSigner设置的签名器
+func WithRequestSigner(signer Signer) RequestOption {
+	return func(opt *requestOption) {
+		opt.signer = signer
+	}
+}
+
+// WithoutSigner 不对请求签名
+func WithoutSigner() RequestOption {
+	return func(opt *requestOption) {
+		opt.signer = nil
+	}
+}
+
+// WithoutResponseVerify 不验证响应
+func WithoutResponseVerify() RequestOption {
+	return func(opt *requestOption) {
+		opt.verifier = nil
+	}
+}
+
+// WithoutBodyTransform 不转换请求和响应body
+func WithoutBodyTransform() RequestOption {
+	return func(opt *requestOption) {
+		opt.transformer = nil
+	}
+}
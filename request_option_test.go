@@ -0,0 +1,47 @@
+package restgo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_RequestOptions(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte(r.Header.Get("X-A") + "," + r.Header.Get("X-B")))
+	}))
+	defer srv.Close()
+	var hooked int
+	var c = New(
+		WithBaseURL("http://127.0.0.1:1"),
+		WithTimeout(10*time.Millisecond),
+		WithGlobalHeader(http.Header{"X-A": {"a"}, "X-B": {"b"}}),
+		WithBeforeHook(func(req IRequest) { hooked++ }),
+	)
+	var req = NewRequest("GET", "/slow").WithOptions(
+		WithRequestBaseURL(srv.URL),
+		WithRequestTimeout(time.Second),
+		WithRequestHeader("X-A", "override"),
+	)
+	var rsp, err = c.Do(context.Background(), req, WithoutGlobalHeader("X-B"), WithoutHooks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	data, err = rsp.Data()
+	if err != nil || string(data) != "override," {
+		t.Errorf("unexpected response: %q %v", data, err)
+	}
+	if hooked != 0 {
+		t.Errorf("expect hooks disabled, got %d calls", hooked)
+	}
+	_, err = c.Do(context.Background(), NewRequest("GET", "/slow"), WithRequestBaseURL(srv.URL))
+	if err == nil {
+		t.Error("expect client timeout")
+	}
+}
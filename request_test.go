@@ -0,0 +1,33 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRequest_Validate(t *testing.T) {
+	var req = NewRequest("POST", "/")
+	req.SetJSONBody(func() {})
+	req.AddFilePath("file", "/not/exist/file")
+	var err = req.Validate()
+	var buildErr *BuildError
+	if !errors.As(err, &buildErr) || buildErr.Op != "SetJSONBody" {
+		t.Fatalf("expect SetJSONBody build error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "AddFilePath") {
+		t.Errorf("expect AddFilePath error, got %v", err)
+	}
+	_, err = New().Do(context.Background(), req)
+	if err == nil {
+		t.Error("expect Do to refuse invalid request")
+	}
+
+	req = NewRequest("POST", "/")
+	req.SetBody("text/plain", strings.NewReader("body"))
+	req.AddFormItem("k", "v")
+	if err = req.Validate(); !errors.Is(err, ErrParamConflict) {
+		t.Errorf("expect param conflict, got %v", err)
+	}
+}
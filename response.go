@@ -19,6 +19,7 @@ type IResponse interface {
 	Data() ([]byte, error)
 	// Pipe : pipe response data to writer
 	// it will automatically close response body
+	// if data has already been read by Data, the buffered data is written instead
 	Pipe(writer io.Writer) error
 	// JSONUnmarshal unmarshal response data to json
 	// it will automatically close response body
@@ -36,15 +37,27 @@ type IResponse interface {
 	// Actually, you can use GetResponse().Body.Close() to close response body,
 	// but this method is more convenient and remind you to close response body.
 	ExplicitCloseBody() error
+	// Attempts number of attempts made to get this response, including retries
+	Attempts() int
+	// RateLimit rate limit info parsed from response headers, nil if absent
+	RateLimit() *RateLimitInfo
+	// FromCache whether the response is served from cache, including revalidated responses
+	FromCache() bool
 }
 
 type Response struct {
-	rsp  *http.Response
-	data []byte
+	rsp       *http.Response
+	data      []byte
+	attempts  int
+	fromCache bool
+	// url 认证前的请求URL，HTTPError使用它，避免泄露认证器添加的查询参数
+	url string
+	// rateLimit 构造响应时解析，相对的重置时间不随读取时间变化
+	rateLimit *RateLimitInfo
 }
 
 func NewResponse(rsp *http.Response) IResponse {
-	return &Response{rsp: rsp}
+	return &Response{rsp: rsp, attempts: 1, rateLimit: ParseRateLimit(rsp.Header)}
 }
 
 func (r *Response) GetResponse() *http.Response {
@@ -66,6 +79,10 @@ func (r *Response) Data() ([]byte, error) {
 }
 
 func (r *Response) Pipe(writer io.Writer) error {
+	if r.data != nil {
+		var _, err = writer.Write(r.data)
+		return err
+	}
 	defer r.rsp.Body.Close()
 	var _, err = io.Copy(writer, r.rsp.Body)
 	return err
@@ -99,3 +116,15 @@ func (r *Response) SaveFile(fileName string) error {
 func (r *Response) ExplicitCloseBody() error {
 	return r.rsp.Body.Close()
 }
+
+func (r *Response) Attempts() int {
+	return r.attempts
+}
+
+func (r *Response) RateLimit() *RateLimitInfo {
+	return r.rateLimit
+}
+
+func (r *Response) FromCache() bool {
+	return r.fromCache
+}
@@ -0,0 +1,206 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const headerRetryAfter = "Retry-After"
+
+// RetryPolicy 重试策略
+type RetryPolicy interface {
+	// Backoff 第attempt次（从1开始）请求结束后调用，rsp和err至多一个非nil
+	// 返回下次重试前的等待时间，retry为false表示不再重试
+	Backoff(attempt int, req *http.Request, rsp *http.Response, err error) (wait time.Duration, retry bool)
+	// AttemptTimeout 单次请求超时时间，0表示不限制
+	AttemptTimeout() time.Duration
+}
+
+// BackoffPolicy 指数退避重试策略
+type BackoffPolicy struct {
+	// MaxAttempts 最大请求次数（含首次请求）
+	MaxAttempts int
+	// BaseDelay 首次重试前的等待时间
+	BaseDelay time.Duration
+	// MaxDelay 退避等待时间上限，0表示不限制
+	MaxDelay time.Duration
+	// Multiplier 每次重试等待时间的增长倍数，小于1时按2处理
+	Multiplier float64
+	// Jitter 等待时间随机抖动比例，取值[0, 1]
+	Jitter float64
+	// Timeout 单次请求超时时间，0表示不限制
+	Timeout time.Duration
+	// RetryConnError 连接错误（含单次请求超时）时是否重试
+	RetryConnError bool
+	// StatusCodes 需要重试的响应状态码
+	StatusCodes []int
+	// RetryNonIdempotent 是否重试非幂等方法（POST、PATCH等）
+	// 未开启时，携带Idempotency-Key头的请求同样会被重试
+	RetryNonIdempotent bool
+	// MaxRetryAfter Retry-After超过该值时不再重试，0表示不限制
+	MaxRetryAfter time.Duration
+}
+
+// DefaultRetryPolicy 默认重试策略：最多3次请求，连接错误及429、502、503、504时重试
+func DefaultRetryPolicy() *BackoffPolicy {
+	return &BackoffPolicy{
+		MaxAttempts:    3,
+		BaseDelay:      100 * time.Millisecond,
+		MaxDelay:       2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryConnError: true,
+		StatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		MaxRetryAfter: time.Minute,
+	}
+}
+
+func (p *BackoffPolicy) Backoff(attempt int, req *http.Request, rsp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts {
+		return 0, false
+	}
+	if !p.RetryNonIdempotent && !isIdempotent(req) {
+		return 0, false
+	}
+	if err != nil {
+		if !p.RetryConnError || !IsConnError(err) {
+			return 0, false
+		}
+		return p.delay(attempt), true
+	}
+	if rsp == nil || !p.retryStatus(rsp.StatusCode) {
+		return 0, false
+	}
+	var wait = p.delay(attempt)
+	if after, ok := ParseRetryAfter(rsp.Header.Get(headerRetryAfter)); ok {
+		if p.MaxRetryAfter > 0 && after > p.MaxRetryAfter {
+			return 0, false
+		}
+		if after > wait {
+			wait = after
+		}
+	}
+	return wait, true
+}
+
+func (p *BackoffPolicy) AttemptTimeout() time.Duration {
+	return p.Timeout
+}
+
+func (p *BackoffPolicy) retryStatus(code int) bool {
+	for _, c := range p.StatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *BackoffPolicy) delay(attempt int) time.Duration {
+	var multiplier = p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	var d = float64(p.BaseDelay) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		// nolint: gosec // jitter does not need crypto random
+		d *= 1 - p.Jitter + 2*p.Jitter*rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// ParseRetryAfter 解析Retry-After，支持秒数和HTTP日期两种格式
+func ParseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	var t, err = http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	var d = time.Until(t)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// IsConnError 是否为连接错误（连接拒绝、重置、超时、DNS等网络错误）
+func IsConnError(err error) bool {
+	var uErr *url.Error
+	if errors.As(err, &uErr) {
+		err = uErr.Err
+	}
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	_, ok := req.Header["Idempotency-Key"]
+	return ok
+}
+
+// cancelBody 单次请求超时的context需要在body关闭后才能释放
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	var err = b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	var timer = time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func drainBody(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxErrorBodySize))
+	_ = body.Close()
+}
@@ -0,0 +1,73 @@
+package restgo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Retry(t *testing.T) {
+	var count int32
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body, _ = io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("unexpected body: %q", body)
+		}
+		if atomic.AddInt32(&count, 1) < 3 {
+			w.Header().Set(headerRetryAfter, "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	var policy = DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	var hooked int
+	var c = New(WithBaseURL(srv.URL), WithRetry(policy), WithRetryHook(func(req IRequest, attempt int, rsp IResponse, err error) {
+		hooked++
+	}))
+	var req = NewRequest("PUT", "/").SetBody("text/plain", strings.NewReader("payload"))
+	var rsp, err = c.Do(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	data, err = rsp.Data()
+	if err != nil || string(data) != "ok" {
+		t.Fatalf("unexpected response: %q %v", data, err)
+	}
+	if rsp.Attempts() != 3 || hooked != 2 {
+		t.Errorf("unexpected attempts: %d, hooked: %d", rsp.Attempts(), hooked)
+	}
+
+	// POST is not retried by default
+	atomic.StoreInt32(&count, 0)
+	req = NewRequest("POST", "/").SetBody("text/plain", strings.NewReader("payload"))
+	rsp, err = c.Do(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rsp.ExplicitCloseBody()
+	if rsp.Attempts() != 1 || rsp.StatusCode() != http.StatusServiceUnavailable {
+		t.Errorf("unexpected attempts: %d, status: %d", rsp.Attempts(), rsp.StatusCode())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d, ok := ParseRetryAfter("3"); !ok || d != 3*time.Second {
+		t.Errorf("unexpected retry after: %v %v", d, ok)
+	}
+	var at = time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if d, ok := ParseRetryAfter(at); !ok || d < 59*time.Minute {
+		t.Errorf("unexpected retry after: %v %v", d, ok)
+	}
+	if _, ok := ParseRetryAfter("soon"); ok {
+		t.Error("expect invalid retry after")
+	}
+}
@@ -0,0 +1,184 @@
+package restgo
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signer 请求签名，每次发送请求（含重试）前在认证之后调用，此时http请求已构造完成
+// body每次调用返回一个新的body reader，请求没有body时返回空reader
+type Signer interface {
+	Sign(ctx context.Context, req *http.Request, body BodyFunc) error
+}
+
+// SignerFunc 函数形式的Signer
+type SignerFunc func(ctx context.Context, req *http.Request, body BodyFunc) error
+
+func (f SignerFunc) Sign(ctx context.Context, req *http.Request, body BodyFunc) error {
+	return f(ctx, req, body)
+}
+
+// signRequest 调用签名器，一次性body会先读入内存以便签名后仍可发送
+func signRequest(ctx context.Context, signer Signer, req *http.Request) error {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		var data, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return err
+		}
+		var body = NewBytesBody(data)
+		req.Body, _ = body.GetBody()
+		req.GetBody = body.GetBody
+	}
+	if req.GetBody == nil {
+		return signer.Sign(ctx, req, func() (io.ReadCloser, error) {
+			return http.NoBody, nil
+		})
+	}
+	var err = signer.Sign(ctx, req, req.GetBody)
+	if err != nil {
+		return err
+	}
+	// seeker body shares one reader with GetBody, reopen it after the signer has read it
+	closeRequestBody(req)
+	req.Body, err = req.GetBody()
+	return err
+}
+
+// hashBody 计算body摘要
+func hashBody(newHash func() hash.Hash, body BodyFunc) ([]byte, error) {
+	var rc, err = body()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var h = newHash()
+	_, err = io.Copy(h, rc)
+	if err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// HMACSigner 通用HMAC签名，签名串为以换行连接的：
+// 方法、路径、按key排序的query、时间戳、随机数、body摘要（hex）以及SignedHeaders中各header的值
+type HMACSigner struct {
+	KeyID  string
+	Secret []byte
+	// Hash 摘要算法，默认sha256
+	Hash func() hash.Hash
+	// SignedHeaders 参与签名的header
+	SignedHeaders []string
+	// SignatureHeader 签名header，默认X-Signature
+	SignatureHeader string
+	// KeyIDHeader 密钥ID header，默认X-Key-Id，KeyID为空时不设置
+	KeyIDHeader string
+	// TimestampHeader 时间戳（unix秒）header，默认X-Timestamp
+	TimestampHeader string
+	// NonceHeader 随机数header，默认X-Nonce
+	NonceHeader string
+	// Encode 签名编码，默认hex
+	Encode func(sig []byte) string
+	// Now 当前时间，默认time.Now
+	Now func() time.Time
+}
+
+// NewHMACSigner 使用默认配置的HMAC-SHA256签名器
+func NewHMACSigner(keyID string, secret []byte) *HMACSigner {
+	return &HMACSigner{KeyID: keyID, Secret: secret}
+}
+
+func (s *HMACSigner) Sign(ctx context.Context, req *http.Request, body BodyFunc) error {
+	var bodyHash, err = hashBody(s.hash(), body)
+	if err != nil {
+		return err
+	}
+	var now = time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	var timestamp = strconv.FormatInt(now().Unix(), 10)
+	var nonce = randomString()
+	req.Header.Set(headerOrDefault(s.TimestampHeader, "X-Timestamp"), timestamp)
+	req.Header.Set(headerOrDefault(s.NonceHeader, "X-Nonce"), nonce)
+	if s.KeyID != "" {
+		req.Header.Set(headerOrDefault(s.KeyIDHeader, "X-Key-Id"), s.KeyID)
+	}
+	var sig = s.Signature(s.StringToSign(req, timestamp, nonce, bodyHash))
+	req.Header.Set(headerOrDefault(s.SignatureHeader, "X-Signature"), sig)
+	return nil
+}
+
+// StringToSign 构造签名串，服务端可用于验证签名
+func (s *HMACSigner) StringToSign(req *http.Request, timestamp, nonce string, bodyHash []byte) string {
+	var lines = []string{
+		req.Method,
+		req.URL.EscapedPath(),
+		sortedQuery(req.URL.Query()),
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash),
+	}
+	for _, name := range s.SignedHeaders {
+		lines = append(lines, strings.TrimSpace(req.Header.Get(name)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Signature 计算签名串的HMAC并编码
+func (s *HMACSigner) Signature(stringToSign string) string {
+	var mac = hmac.New(s.hash(), s.Secret)
+	_, _ = mac.Write([]byte(stringToSign))
+	if s.Encode != nil {
+		return s.Encode(mac.Sum(nil))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *HMACSigner) hash() func() hash.Hash {
+	if s.Hash == nil {
+		return sha256.New
+	}
+	return s.Hash
+}
+
+// sortedQuery 按key、value排序的query串
+func sortedQuery(q url.Values) string {
+	var keys = make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	for _, k := range keys {
+		var values = append([]string(nil), q[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			if buf.Len() != 0 {
+				buf.WriteByte('&')
+			}
+			buf.WriteString(url.QueryEscape(k))
+			buf.WriteByte('=')
+			buf.WriteString(url.QueryEscape(v))
+		}
+	}
+	return buf.String()
+}
+
+func headerOrDefault(name, def string) string {
+	if name == "" {
+		return def
+	}
+	return name
+}
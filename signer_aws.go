@@ -0,0 +1,183 @@
+package restgo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	awsAlgorithm       = "AWS4-HMAC-SHA256"
+	awsTimeFormat      = "20060102T150405Z"
+	awsUnsignedPayload = "UNSIGNED-PAYLOAD"
+	headerAmzDate      = "X-Amz-Date"
+	headerAmzContent   = "X-Amz-Content-Sha256"
+	headerAmzToken     = "X-Amz-Security-Token"
+)
+
+// AWSCredentials AWS访问凭证
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSSigner AWS Signature Version 4签名
+type AWSSigner struct {
+	Credentials AWSCredentials
+	Region      string
+	Service     string
+	// UnsignedPayload 不计算body摘要，仅S3支持
+	UnsignedPayload bool
+	// Now 当前时间，默认time.Now
+	Now func() time.Time
+}
+
+// NewAWSSigner 新建SigV4签名器
+func NewAWSSigner(credentials AWSCredentials, region, service string) *AWSSigner {
+	return &AWSSigner{Credentials: credentials, Region: region, Service: service}
+}
+
+func (s *AWSSigner) Sign(ctx context.Context, req *http.Request, body BodyFunc) error {
+	var now = time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	var t = now().UTC()
+	var payloadHash = awsUnsignedPayload
+	if !s.UnsignedPayload {
+		var sum, err = hashBody(sha256.New, body)
+		if err != nil {
+			return err
+		}
+		payloadHash = hex.EncodeToString(sum)
+	}
+	req.Header.Set(headerAmzDate, t.Format(awsTimeFormat))
+	if s.Service == "s3" {
+		req.Header.Set(headerAmzContent, payloadHash)
+	}
+	if s.Credentials.SessionToken != "" {
+		req.Header.Set(headerAmzToken, s.Credentials.SessionToken)
+	}
+
+	var canonicalHeaders, signedHeaders = s.canonicalHeaders(req)
+	var canonicalRequest = strings.Join([]string{
+		req.Method,
+		s.canonicalURI(req.URL),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	var date = t.Format("20060102")
+	var scope = date + "/" + s.Region + "/" + s.Service + "/aws4_request"
+	var requestHash = sha256.Sum256([]byte(canonicalRequest))
+	var stringToSign = awsAlgorithm + "\n" + t.Format(awsTimeFormat) + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	var key = hmacSHA256([]byte("AWS4"+s.Credentials.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+	var signature = hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set(headerAuthorization, awsAlgorithm+" Credential="+s.Credentials.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+	return nil
+}
+
+// canonicalURI S3以外的服务路径需要编码两次
+func (s *AWSSigner) canonicalURI(u *url.URL) string {
+	var p = u.Path
+	if p == "" {
+		return "/"
+	}
+	p = awsEscape(p, false)
+	if s.Service != "s3" {
+		p = awsEscape(p, false)
+	}
+	return p
+}
+
+// canonicalHeaders 签名host、content-type、content-md5以及所有x-amz-*头，返回的规范头以换行结尾
+func (s *AWSSigner) canonicalHeaders(req *http.Request) (string, string) {
+	var host = req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	var headers = map[string]string{"host": host}
+	for name, values := range req.Header {
+		var lower = strings.ToLower(name)
+		if lower != "content-type" && lower != "content-md5" && !strings.HasPrefix(lower, "x-amz-") {
+			continue
+		}
+		var trimmed = make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[lower] = strings.Join(trimmed, ",")
+	}
+	var names = make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name)
+		canonical.WriteByte(':')
+		canonical.WriteString(headers[name])
+		canonical.WriteByte('\n')
+	}
+	return canonical.String(), strings.Join(names, ";")
+}
+
+// canonicalQuery 按编码后的参数名排序，参数名相同时按编码后的值排序
+func canonicalQuery(q url.Values) string {
+	var pairs = make([][2]string, 0, len(q))
+	for k, values := range q {
+		for _, v := range values {
+			pairs = append(pairs, [2]string{awsEscape(k, true), awsEscape(v, true)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	var encoded = make([]string, len(pairs))
+	for i, pair := range pairs {
+		encoded[i] = pair[0] + "=" + pair[1]
+	}
+	return strings.Join(encoded, "&")
+}
+
+// awsEscape 除unreserved字符（A-Z a-z 0-9 - _ . ~）外全部编码
+func awsEscape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		var c = s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	var mac = hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,122 @@
+package restgo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACSigner(t *testing.T) {
+	var signer = NewHMACSigner("k1", []byte("secret"))
+	signer.SignedHeaders = []string{headerContentType}
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body, _ = io.ReadAll(r.Body)
+		var sum = sha256.Sum256(body)
+		var expect = signer.Signature(signer.StringToSign(r, r.Header.Get("X-Timestamp"), r.Header.Get("X-Nonce"), sum[:]))
+		if r.Header.Get("X-Key-Id") != "k1" || r.Header.Get("X-Signature") != expect {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithSigner(signer))
+	var cases = []IRequest{
+		NewRequest("GET", "/a").AddURLQuery("b", "2").AddURLQuery("a", "1"),
+		NewRequest("POST", "/a").SetJSONBody(map[string]int{"a": 1}),
+		// one-shot body is buffered before signing
+		NewRequest("PUT", "/a").SetBody("text/plain", strings.NewReader("one-shot")),
+	}
+	for _, req := range cases {
+		var rsp, err = c.Do(context.Background(), req)
+		if err != nil || rsp.StatusCode() != http.StatusOK {
+			t.Errorf("expect valid signature for %s", req.GetMethod())
+		}
+	}
+}
+
+// TestAWSSigner_Vanilla get-vanilla cases of the AWS SigV4 test suite
+func TestAWSSigner_Vanilla(t *testing.T) {
+	var signer = NewAWSSigner(AWSCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}, "us-east-1", "service")
+	signer.Now = func() time.Time {
+		return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	}
+	var signatures = map[string]string{
+		"/":                             "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		"/?Param2=value2&Param1=value1": "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+	}
+	for uri, signature := range signatures {
+		var req, _ = http.NewRequest("GET", "https://example.amazonaws.com"+uri, nil)
+		var err = signRequest(context.Background(), signer, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expect = "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+			"SignedHeaders=host;x-amz-date, Signature=" + signature
+		if got := req.Header.Get(headerAuthorization); got != expect {
+			t.Errorf("%s: expect %s, got %s", uri, expect, got)
+		}
+	}
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	// keys sort before the keys they prefix, values only break ties
+	var q = url.Values{"a1": {"x"}, "a": {"y", "b"}, "a-b": {"1"}, "a b": {"2"}}
+	var expect = "a=b&a=y&a%20b=2&a-b=1&a1=x"
+	if got := canonicalQuery(q); got != expect {
+		t.Errorf("expect %s, got %s", expect, got)
+	}
+}
+
+func TestAWSSigner_S3(t *testing.T) {
+	var creds = AWSCredentials{AccessKeyID: "AK", SecretAccessKey: "SK", SessionToken: "ST"}
+	var objects = map[string]string{}
+	// S3-compatible stand-in: verifies payload hash and signature then stores the object
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body, _ = io.ReadAll(r.Body)
+		var sum = sha256.Sum256(body)
+		if r.Header.Get(headerAmzContent) != hex.EncodeToString(sum[:]) || r.Header.Get(headerAmzToken) != "ST" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var date, _ = time.Parse(awsTimeFormat, r.Header.Get(headerAmzDate))
+		var verify = NewAWSSigner(creds, "us-east-1", "s3")
+		verify.Now = func() time.Time { return date }
+		var auth = r.Header.Get(headerAuthorization)
+		var check, _ = http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+		check.Header = r.Header.Clone()
+		_ = verify.Sign(context.Background(), check, NewBytesBody(body).GetBody)
+		if check.Header.Get(headerAuthorization) != auth {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.Method == "PUT" {
+			objects[r.URL.Path] = string(body)
+			return
+		}
+		_, _ = w.Write([]byte(objects[r.URL.Path]))
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithSigner(NewAWSSigner(creds, "us-east-1", "s3")))
+	var rsp, err = c.Do(context.Background(), NewRequest("PUT", "/bucket/a b.txt").
+		SetBody("text/plain", strings.NewReader("hello")))
+	if err != nil || rsp.StatusCode() != http.StatusOK {
+		t.Fatalf("put failed: %v %v", rsp, err)
+	}
+	var data []byte
+	rsp, err = c.Get(context.Background(), "/bucket/a b.txt")
+	if err == nil {
+		data, err = rsp.Data()
+	}
+	if err != nil || string(data) != "hello" {
+		t.Errorf("expect hello, got %q %v", data, err)
+	}
+}
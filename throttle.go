@@ -0,0 +1,247 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitInfo 响应头中的限流信息
+type RateLimitInfo struct {
+	// Limit 窗口内允许的请求数，未知时为-1
+	Limit int
+	// Remaining 窗口内剩余的请求数
+	Remaining int
+	// Reset 窗口重置时间，未知时为零值
+	Reset time.Time
+}
+
+// unixTimestampThreshold X-RateLimit-Reset大于该值时视为unix时间戳，否则视为秒数
+const unixTimestampThreshold = 1e9
+
+// ParseRateLimit 解析限流响应头，支持X-RateLimit-*、RateLimit-*以及IETF RateLimit/RateLimit-Policy
+// 没有限流信息时返回nil
+func ParseRateLimit(header http.Header) *RateLimitInfo {
+	var now = time.Now()
+	if v := header.Get("RateLimit"); v != "" {
+		return parseStructuredRateLimit(v, header.Get("RateLimit-Policy"), now)
+	}
+	for _, prefix := range []string{"RateLimit-", "X-RateLimit-"} {
+		var remaining, err = strconv.Atoi(strings.TrimSpace(header.Get(prefix + "Remaining")))
+		if err != nil {
+			continue
+		}
+		var info = &RateLimitInfo{Limit: -1, Remaining: remaining}
+		if limit, lErr := strconv.Atoi(firstItem(header.Get(prefix + "Limit"))); lErr == nil {
+			info.Limit = limit
+		}
+		if reset, rErr := strconv.ParseInt(strings.TrimSpace(header.Get(prefix+"Reset")), 10, 64); rErr == nil {
+			if reset > unixTimestampThreshold {
+				info.Reset = time.Unix(reset, 0)
+			} else {
+				info.Reset = now.Add(time.Duration(reset) * time.Second)
+			}
+		}
+		return info
+	}
+	return nil
+}
+
+// parseStructuredRateLimit 解析 limit=100, remaining=50, reset=30 或 "default";r=50;t=30
+func parseStructuredRateLimit(v, policy string, now time.Time) *RateLimitInfo {
+	var params = structuredParams(v)
+	var info = &RateLimitInfo{Limit: -1, Remaining: -1}
+	if n, ok := params["remaining"]; ok {
+		info.Remaining = n
+	} else if n, ok = params["r"]; ok {
+		info.Remaining = n
+	}
+	if info.Remaining < 0 {
+		return nil
+	}
+	if n, ok := params["limit"]; ok {
+		info.Limit = n
+	} else if n, ok = structuredParams(policy)["q"]; ok {
+		info.Limit = n
+	}
+	if n, ok := params["reset"]; ok {
+		info.Reset = now.Add(time.Duration(n) * time.Second)
+	} else if n, ok = params["t"]; ok {
+		info.Reset = now.Add(time.Duration(n) * time.Second)
+	}
+	return info
+}
+
+func structuredParams(v string) map[string]int {
+	var params = make(map[string]int)
+	for _, item := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' }) {
+		var kv = strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		var n, err = strconv.Atoi(strings.Trim(strings.TrimSpace(kv[1]), `"`))
+		if err != nil {
+			continue
+		}
+		var key = strings.ToLower(strings.TrimSpace(kv[0]))
+		if _, ok := params[key]; !ok {
+			params[key] = n
+		}
+	}
+	return params
+}
+
+// firstItem X-RateLimit-Limit可能为 100, 100;w=60 的形式
+func firstItem(v string) string {
+	if i := strings.IndexAny(v, ",;"); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v)
+}
+
+// ThrottleConfig 自适应限流配置
+type ThrottleConfig struct {
+	// SlowdownRatio 剩余请求数低于Limit的该比例时，将剩余请求均匀分布到窗口重置前，默认0.1
+	SlowdownRatio float64
+	// MaxWait 单次等待的上限，默认1分钟
+	// 需要暂停的时间超过MaxWait时不发送请求，直接返回ThrottledError
+	MaxWait time.Duration
+}
+
+// ErrThrottled 服务端要求暂停的时间超过ThrottleConfig.MaxWait，请求未发送
+var ErrThrottled = errors.New("restgo: request throttled")
+
+// ThrottledError 请求被限流时返回的错误，errors.Is(err, ErrThrottled)为true
+type ThrottledError struct {
+	Host string
+	// Wait 距离可以发送请求还需等待的时间
+	Wait time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return ErrThrottled.Error() + ": " + e.Host + " is paused for " + e.Wait.String()
+}
+
+func (e *ThrottledError) Is(target error) bool {
+	return target == ErrThrottled
+}
+
+// Throttler 根据响应中的限流信息按host自适应降速，剩余为0时暂停到窗口重置
+type Throttler struct {
+	cfg   ThrottleConfig
+	lock  sync.Mutex
+	hosts map[string]*throttleState
+}
+
+type throttleState struct {
+	pauseUntil time.Time
+	reset      time.Time
+	spacing    time.Duration
+	next       time.Time
+}
+
+func NewThrottler(cfg ThrottleConfig) *Throttler {
+	if cfg.SlowdownRatio <= 0 {
+		cfg.SlowdownRatio = 0.1
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = time.Minute
+	}
+	return &Throttler{cfg: cfg, hosts: make(map[string]*throttleState)}
+}
+
+// Middleware 自适应限流中间件
+func (t *Throttler) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			var wait, err = t.delay(req.URL.Host)
+			if err == nil {
+				err = sleepContext(ctx, wait)
+			}
+			if err != nil {
+				closeRequestBody(req)
+				return nil, err
+			}
+			// nolint: bodyclose
+			var rsp, rErr = next(ctx, req)
+			if rErr == nil {
+				t.observe(req.URL.Host, rsp)
+			}
+			return rsp, rErr
+		}
+	}
+}
+
+// delay 计算发送前需要等待的时间，并为后续请求预留间隔，超过MaxWait时返回ThrottledError
+func (t *Throttler) delay(host string) (time.Duration, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var s, ok = t.hosts[host]
+	if !ok {
+		return 0, nil
+	}
+	var now = time.Now()
+	if !s.reset.IsZero() && now.After(s.reset) {
+		// window has been reset
+		delete(t.hosts, host)
+		return 0, nil
+	}
+	var at = now
+	if s.pauseUntil.After(at) {
+		at = s.pauseUntil
+	}
+	if s.next.After(at) {
+		at = s.next
+	}
+	var wait = at.Sub(now)
+	if wait > t.cfg.MaxWait {
+		return 0, &ThrottledError{Host: host, Wait: wait}
+	}
+	if s.spacing > 0 {
+		s.next = at.Add(s.spacing)
+	}
+	return wait, nil
+}
+
+func (t *Throttler) observe(host string, rsp *http.Response) {
+	var info = ParseRateLimit(rsp.Header)
+	var retryAfter, hasRetryAfter = time.Duration(0), false
+	if rsp.StatusCode == http.StatusTooManyRequests || rsp.StatusCode == http.StatusServiceUnavailable {
+		retryAfter, hasRetryAfter = ParseRetryAfter(rsp.Header.Get(headerRetryAfter))
+	}
+	if info == nil && !hasRetryAfter {
+		return
+	}
+	var now = time.Now()
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var s, ok = t.hosts[host]
+	if !ok {
+		s = &throttleState{}
+		t.hosts[host] = s
+	}
+	if hasRetryAfter {
+		s.pauseUntil = now.Add(retryAfter)
+	}
+	if info == nil {
+		return
+	}
+	s.reset = info.Reset
+	s.spacing = 0
+	if info.Reset.IsZero() {
+		return
+	}
+	if info.Remaining <= 0 {
+		if info.Reset.After(s.pauseUntil) {
+			s.pauseUntil = info.Reset
+		}
+		return
+	}
+	if info.Limit > 0 && float64(info.Remaining) < float64(info.Limit)*t.cfg.SlowdownRatio {
+		s.spacing = info.Reset.Sub(now) / time.Duration(info.Remaining)
+	}
+}
@@ -0,0 +1,78 @@
+package restgo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	var reset = time.Now().Add(time.Minute).Unix()
+	var cases = []http.Header{
+		{"X-Ratelimit-Limit": {"100"}, "X-Ratelimit-Remaining": {"5"}, "X-Ratelimit-Reset": {strconv.FormatInt(reset, 10)}},
+		{"Ratelimit": {"limit=100, remaining=5, reset=60"}},
+		{"Ratelimit": {`"default";r=5;t=60`}, "Ratelimit-Policy": {`"default";q=100;w=60`}},
+	}
+	for _, header := range cases {
+		var info = ParseRateLimit(header)
+		if info == nil || info.Limit != 100 || info.Remaining != 5 {
+			t.Fatalf("unexpected info %+v for %v", info, header)
+		}
+		if d := time.Until(info.Reset); d < 58*time.Second || d > time.Minute {
+			t.Errorf("unexpected reset %v for %v", d, header)
+		}
+	}
+	if ParseRateLimit(http.Header{}) != nil {
+		t.Error("expect nil info")
+	}
+}
+
+func TestThrottler(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1")
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithThrottler(NewThrottler(ThrottleConfig{MaxWait: 2 * time.Second})))
+	var start = time.Now()
+	for i := 0; i < 2; i++ {
+		var rsp, err = c.Get(context.Background(), "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = rsp.ExplicitCloseBody()
+		var info = rsp.RateLimit()
+		if info == nil || info.Remaining != 0 {
+			t.Fatalf("unexpected rate limit: %+v", info)
+		}
+		// relative reset is resolved once when the response is received
+		time.Sleep(10 * time.Millisecond)
+		if again := rsp.RateLimit(); !again.Reset.Equal(info.Reset) {
+			t.Errorf("reset moved from %v to %v", info.Reset, again.Reset)
+		}
+	}
+	if cost := time.Since(start); cost < 50*time.Millisecond {
+		t.Errorf("expect throttled, cost %v", cost)
+	}
+
+	// pause longer than MaxWait fails fast instead of sending before the window resets
+	c = New(WithBaseURL(srv.URL), WithThrottler(NewThrottler(ThrottleConfig{MaxWait: 50 * time.Millisecond})))
+	var rsp, err = c.Get(context.Background(), "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rsp.ExplicitCloseBody()
+	start = time.Now()
+	_, err = c.Get(context.Background(), "/")
+	var tErr *ThrottledError
+	if !errors.Is(err, ErrThrottled) || !errors.As(err, &tErr) || tErr.Wait <= 50*time.Millisecond {
+		t.Errorf("expect throttled error, got %v", err)
+	}
+	if cost := time.Since(start); cost > 50*time.Millisecond {
+		t.Errorf("expect fail fast, cost %v", cost)
+	}
+}
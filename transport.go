@@ -0,0 +1,245 @@
+package restgo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TransportOption 配置http.Transport，通过WithTransportOptions设置
+// 所有TransportOption在创建客户端时作用于WithTransport设置的transport的副本（未设置时为默认transport），与选项顺序无关
+// WithTransport设置的不是*http.Transport时无法应用TransportOption，每次请求都返回错误
+type TransportOption func(t *http.Transport) error
+
+// buildTransport 由基础transport和TransportOption构造transport，出错时返回的transport每次请求都返回该错误
+func buildTransport(base http.RoundTripper, opts []TransportOption) http.RoundTripper {
+	if len(opts) == 0 {
+		if base == nil {
+			return newDefaultTransport()
+		}
+		return base
+	}
+	var t *http.Transport
+	switch b := base.(type) {
+	case nil:
+		t = newDefaultTransport()
+	case *http.Transport:
+		t = b.Clone()
+	default:
+		return errTransport{err: fmt.Errorf("transport options require *http.Transport, got %T", base)}
+	}
+	for _, fn := range opts {
+		var err = fn(t)
+		if err != nil {
+			return errTransport{err: err}
+		}
+	}
+	return t
+}
+
+// newDefaultTransport 未设置WithTransport时的transport，是否设置TransportOption不影响其他配置
+func newDefaultTransport() *http.Transport {
+	return &http.Transport{Proxy: http.ProxyFromEnvironment}
+}
+
+// errTransport 构造transport失败时使用，在发送请求时返回构造错误
+type errTransport struct {
+	err error
+}
+
+func (t errTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	closeRequestBody(req)
+	return nil, fmt.Errorf("restgo: invalid transport config: %w", t.err)
+}
+
+func tlsConfig(t *http.Transport) *tls.Config {
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return t.TLSClientConfig
+}
+
+// TLSRootCAs 从PEM文件或目录（目录下所有文件）加载CA证书，追加到已配置的CA或系统CA
+func TLSRootCAs(paths ...string) TransportOption {
+	return func(t *http.Transport) error {
+		var cfg = tlsConfig(t)
+		var pool = cfg.RootCAs
+		if pool == nil {
+			var err error
+			pool, err = x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+		} else {
+			pool = pool.Clone()
+		}
+		for _, p := range paths {
+			var err = appendCertsFromPath(pool, p)
+			if err != nil {
+				return err
+			}
+		}
+		cfg.RootCAs = pool
+		return nil
+	}
+}
+
+func appendCertsFromPath(pool *x509.CertPool, path string) error {
+	var info, err = os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		var data []byte
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return errors.New("restgo: no certificate found in " + path)
+		}
+		return nil
+	}
+	var entries []os.DirEntry
+	entries, err = os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		var data []byte
+		data, err = os.ReadFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return err
+		}
+		pool.AppendCertsFromPEM(data)
+	}
+	return nil
+}
+
+// TLSRootCAPool 使用指定的CA证书池
+func TLSRootCAPool(pool *x509.CertPool) TransportOption {
+	return func(t *http.Transport) error {
+		tlsConfig(t).RootCAs = pool
+		return nil
+	}
+}
+
+// TLSClientCertFile 从PEM文件加载客户端证书
+func TLSClientCertFile(certFile, keyFile string) TransportOption {
+	return func(t *http.Transport) error {
+		var cert, err = tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		var cfg = tlsConfig(t)
+		cfg.Certificates = append(cfg.Certificates, cert)
+		return nil
+	}
+}
+
+// TLSClientCert 添加客户端证书
+func TLSClientCert(cert tls.Certificate) TransportOption {
+	return func(t *http.Transport) error {
+		var cfg = tlsConfig(t)
+		cfg.Certificates = append(cfg.Certificates, cert)
+		return nil
+	}
+}
+
+// TLSMinVersion 最低TLS版本，默认TLS 1.2
+func TLSMinVersion(version uint16) TransportOption {
+	return func(t *http.Transport) error {
+		tlsConfig(t).MinVersion = version
+		return nil
+	}
+}
+
+// TLSCipherSuites TLS 1.2及以下使用的密码套件，TLS 1.3的套件不可配置
+func TLSCipherSuites(suites ...uint16) TransportOption {
+	return func(t *http.Transport) error {
+		tlsConfig(t).CipherSuites = suites
+		return nil
+	}
+}
+
+// TLSServerName 覆盖SNI及证书校验使用的主机名
+func TLSServerName(name string) TransportOption {
+	return func(t *http.Transport) error {
+		tlsConfig(t).ServerName = name
+		return nil
+	}
+}
+
+// TLSInsecureSkipVerify 不校验服务端证书，仅用于开发环境
+func TLSInsecureSkipVerify() TransportOption {
+	return func(t *http.Transport) error {
+		// nolint: gosec
+		tlsConfig(t).InsecureSkipVerify = true
+		return nil
+	}
+}
+
+// ProxyURL 使用指定代理，空字符串表示不使用代理（包括环境变量中的代理）
+func ProxyURL(proxy string) TransportOption {
+	return func(t *http.Transport) error {
+		if proxy == "" {
+			t.Proxy = nil
+			return nil
+		}
+		var u, err = url.Parse(proxy)
+		if err != nil {
+			return err
+		}
+		t.Proxy = http.ProxyURL(u)
+		return nil
+	}
+}
+
+// DialTimeout 建立TCP连接的超时时间
+func DialTimeout(timeout time.Duration) TransportOption {
+	return func(t *http.Transport) error {
+		t.DialContext = (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext
+		return nil
+	}
+}
+
+// TLSHandshakeTimeout TLS握手超时时间
+func TLSHandshakeTimeout(timeout time.Duration) TransportOption {
+	return func(t *http.Transport) error {
+		t.TLSHandshakeTimeout = timeout
+		return nil
+	}
+}
+
+// IdlePool 空闲连接池大小，maxIdle为所有host的总数，0表示不限制
+func IdlePool(maxIdle, maxIdlePerHost int, idleTimeout time.Duration) TransportOption {
+	return func(t *http.Transport) error {
+		t.MaxIdleConns = maxIdle
+		t.MaxIdleConnsPerHost = maxIdlePerHost
+		t.IdleConnTimeout = idleTimeout
+		return nil
+	}
+}
+
+// HTTP2 是否尝试使用HTTP/2，自定义TLS或拨号配置时需开启才会使用HTTP/2
+func HTTP2(enable bool) TransportOption {
+	return func(t *http.Transport) error {
+		t.ForceAttemptHTTP2 = enable
+		if !enable {
+			t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		} else {
+			t.TLSNextProto = nil
+		}
+		return nil
+	}
+}
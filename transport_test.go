@@ -0,0 +1,114 @@
+package restgo
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithTransportOptions_Compose(t *testing.T) {
+	var base = &http.Transport{Proxy: http.ProxyFromEnvironment, ResponseHeaderTimeout: 7 * time.Second}
+	var c = New(
+		WithTransportOptions(TLSMinVersion(tls.VersionTLS13), TLSServerName("api.local")),
+		WithCert(nil, tls.Certificate{}),
+		WithTransport(base),
+		WithTransportOptions(IdlePool(0, 4, time.Minute), TLSHandshakeTimeout(time.Second)),
+	)
+	var tr, ok = c.client.Transport.(*http.Transport)
+	if !ok || tr == base {
+		t.Fatalf("expect a copy of the base transport, got %T", c.client.Transport)
+	}
+	var cfg = tr.TLSClientConfig
+	if tr.Proxy == nil || tr.ResponseHeaderTimeout != 7*time.Second || tr.MaxIdleConnsPerHost != 4 || tr.TLSHandshakeTimeout != time.Second ||
+		cfg.MinVersion != tls.VersionTLS13 || cfg.ServerName != "api.local" || len(cfg.Certificates) != 1 {
+		t.Errorf("options are not composed: %+v %+v", tr, cfg)
+	}
+	if base.TLSClientConfig != nil && base.TLSClientConfig.ServerName != "" {
+		t.Error("base transport must not be modified")
+	}
+	// derived clients share the transport unless they change it
+	if c.With(WithHeader("a", "b")).client.Transport != tr {
+		t.Error("expect shared transport")
+	}
+	if c.With(WithTransportOptions(HTTP2(false))).client.Transport == tr {
+		t.Error("expect new transport")
+	}
+}
+
+func TestWithTransportOptions_DefaultBase(t *testing.T) {
+	var plain, ok = New().client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expect *http.Transport, got %T", New().client.Transport)
+	}
+	var tr *http.Transport
+	tr, ok = New(WithTransportOptions(TLSMinVersion(tls.VersionTLS13))).client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatal("expect *http.Transport")
+	}
+	// options only change what they configure, pooling and HTTP/2 stay the same as without options
+	if tr.Proxy == nil || tr.ForceAttemptHTTP2 != plain.ForceAttemptHTTP2 || tr.MaxIdleConns != plain.MaxIdleConns ||
+		tr.IdleConnTimeout != plain.IdleConnTimeout || tr.TLSHandshakeTimeout != plain.TLSHandshakeTimeout {
+		t.Errorf("default base changed by options: %+v", tr)
+	}
+}
+
+func TestTLSRootCAs(t *testing.T) {
+	var srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	var dir = t.TempDir()
+	var data = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(filepath.Join(dir, "ca.pem"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var rsp, err = New(WithTransportOptions(TLSRootCAs(dir), TLSServerName("example.com"))).Get(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body, _ = rsp.Data()
+	if string(body) != "ok" {
+		t.Errorf("expect ok, got %s", body)
+	}
+
+	_, err = New().Get(context.Background(), srv.URL)
+	if err == nil {
+		t.Error("expect unknown authority error")
+	}
+	_, err = New(WithTransportOptions(TLSRootCAs(filepath.Join(dir, "missing.pem")))).Get(context.Background(), srv.URL)
+	if err == nil {
+		t.Error("expect config error")
+	}
+}
+
+func TestWithTransportOptions_CustomTransport(t *testing.T) {
+	var called bool
+	var custom = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		called = true
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+	// options cannot be applied to a custom round tripper, requests fail instead of silently dropping them
+	var c = New(WithTransport(custom), WithCert(nil, tls.Certificate{}))
+	var _, err = c.Get(context.Background(), "https://example.com/")
+	if err == nil || !strings.Contains(err.Error(), "invalid transport config") || called {
+		t.Errorf("expect transport config error, got %v %v", err, called)
+	}
+	_, err = New(WithTransport(custom)).Get(context.Background(), "https://example.com/")
+	if err != nil || !called {
+		t.Errorf("expect custom transport to be used, got %v %v", err, called)
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
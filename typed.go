@@ -0,0 +1,68 @@
+package restgo
+
+import (
+	"context"
+)
+
+// DoInto 执行请求，检查状态码并将JSON响应解析为T
+// 非2xx响应返回*HTTPError
+func DoInto[T any](ctx context.Context, c *Client, req IRequest) (T, error) {
+	var out T
+	var rsp, err = c.Do(ctx, req)
+	if err != nil {
+		return out, err
+	}
+	err = decodeInto(rsp, &out)
+	return out, err
+}
+
+// GetJSON 发起GET请求并将JSON响应解析为T
+func GetJSON[T any](ctx context.Context, c *Client, resource string, params ...IParam) (T, error) {
+	var req = NewRequest("GET", resource)
+	req.AddParams(params...)
+	return DoInto[T](ctx, c, req)
+}
+
+// DeleteJSON 发起DELETE请求并将JSON响应解析为T
+func DeleteJSON[T any](ctx context.Context, c *Client, resource string, params ...IParam) (T, error) {
+	var req = NewRequest("DELETE", resource)
+	req.AddParams(params...)
+	return DoInto[T](ctx, c, req)
+}
+
+// PostJSON 以JSON作为body发起POST请求并将JSON响应解析为Rsp
+func PostJSON[Req, Rsp any](ctx context.Context, c *Client, resource string, body Req, params ...IParam) (Rsp, error) {
+	return sendJSON[Req, Rsp](ctx, c, "POST", resource, body, params...)
+}
+
+// PutJSON 以JSON作为body发起PUT请求并将JSON响应解析为Rsp
+func PutJSON[Req, Rsp any](ctx context.Context, c *Client, resource string, body Req, params ...IParam) (Rsp, error) {
+	return sendJSON[Req, Rsp](ctx, c, "PUT", resource, body, params...)
+}
+
+// PatchJSON 以JSON作为body发起PATCH请求并将JSON响应解析为Rsp
+func PatchJSON[Req, Rsp any](ctx context.Context, c *Client, resource string, body Req, params ...IParam) (Rsp, error) {
+	return sendJSON[Req, Rsp](ctx, c, "PATCH", resource, body, params...)
+}
+
+func sendJSON[Req, Rsp any](ctx context.Context, c *Client, method, resource string, body Req, params ...IParam) (Rsp, error) {
+	var req = NewRequest(method, resource)
+	req.AddParams(params...)
+	req.SetJSONBody(body)
+	return DoInto[Rsp](ctx, c, req)
+}
+
+func decodeInto(rsp IResponse, out interface{}) error {
+	var data, err = rsp.Data()
+	if err != nil {
+		return err
+	}
+	if !isSuccessStatus(rsp.StatusCode()) {
+		return NewHTTPError(rsp)
+	}
+	// empty body, e.g. 204 No Content
+	if len(data) == 0 {
+		return nil
+	}
+	return rsp.JSONUnmarshal(out)
+}
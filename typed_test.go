@@ -0,0 +1,38 @@
+package restgo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type typedItem struct {
+	Name string `json:"name"`
+}
+
+func TestPostJSON(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body, _ = io.ReadAll(r.Body)
+		w.Header().Set(headerContentType, "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL))
+	var out, err = PostJSON[typedItem, typedItem](context.Background(), c, "/echo", typedItem{Name: "restgo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "restgo" {
+		t.Errorf("unexpected name: %s", out.Name)
+	}
+	_, err = GetJSON[typedItem](context.Background(), c, "/missing")
+	if !IsNotFound(err) {
+		t.Errorf("expect 404 status error, got %v", err)
+	}
+}
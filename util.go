@@ -78,3 +78,38 @@ func Download(ctx context.Context, url string) ([]byte, error) {
 	}
 	return rsp.Data()
 }
+
+// Get 使用DefaultClient发起GET请求
+func Get(ctx context.Context, resource string, params ...IParam) (IResponse, error) {
+	return DefaultClient.Get(ctx, resource, params...)
+}
+
+// Post 使用DefaultClient发起POST请求
+func Post(ctx context.Context, resource string, params ...IParam) (IResponse, error) {
+	return DefaultClient.Post(ctx, resource, params...)
+}
+
+// Put 使用DefaultClient发起PUT请求
+func Put(ctx context.Context, resource string, params ...IParam) (IResponse, error) {
+	return DefaultClient.Put(ctx, resource, params...)
+}
+
+// Delete 使用DefaultClient发起DELETE请求
+func Delete(ctx context.Context, resource string, params ...IParam) (IResponse, error) {
+	return DefaultClient.Delete(ctx, resource, params...)
+}
+
+// Patch 使用DefaultClient发起PATCH请求
+func Patch(ctx context.Context, resource string, params ...IParam) (IResponse, error) {
+	return DefaultClient.Patch(ctx, resource, params...)
+}
+
+// Head 使用DefaultClient发起HEAD请求
+func Head(ctx context.Context, resource string, params ...IParam) (IResponse, error) {
+	return DefaultClient.Head(ctx, resource, params...)
+}
+
+// Options 使用DefaultClient发起OPTIONS请求
+func Options(ctx context.Context, resource string, params ...IParam) (IResponse, error) {
+	return DefaultClient.Options(ctx, resource, params...)
+}
@@ -0,0 +1,208 @@
+package restgo
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrVerification 响应签名验证失败
+	ErrVerification = errors.New("restgo: response verification failed")
+	// ErrSignatureMissing 响应缺少签名
+	ErrSignatureMissing = errors.New("signature is missing")
+	// ErrSignatureInvalid 签名不正确
+	ErrSignatureInvalid = errors.New("signature is invalid")
+	// ErrUnknownKey 未配置签名使用的密钥
+	ErrUnknownKey = errors.New("unknown key")
+	// ErrTimestampSkew 签名时间戳超出允许范围
+	ErrTimestampSkew = errors.New("timestamp is out of range")
+)
+
+// VerificationError 响应验证失败时Client.Do返回的错误，errors.Is(err, ErrVerification)为true
+type VerificationError struct {
+	StatusCode int
+	KeyID      string
+	Err        error
+}
+
+func (e *VerificationError) Error() string {
+	return ErrVerification.Error() + ": " + e.Err.Error()
+}
+
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
+
+func (e *VerificationError) Is(target error) bool {
+	return target == ErrVerification
+}
+
+// ResponseVerifier 响应验证，body为已读取的完整响应body，返回错误时Client.Do失败
+type ResponseVerifier interface {
+	Verify(ctx context.Context, rsp *http.Response, body []byte) error
+}
+
+// ResponseVerifierFunc 函数形式的ResponseVerifier
+type ResponseVerifierFunc func(ctx context.Context, rsp *http.Response, body []byte) error
+
+func (f ResponseVerifierFunc) Verify(ctx context.Context, rsp *http.Response, body []byte) error {
+	return f(ctx, rsp, body)
+}
+
+// verifyResponse 读取响应body后验证，错误统一包装为VerificationError
+func verifyResponse(ctx context.Context, verifier ResponseVerifier, rsp *Response) error {
+	var body, err = rsp.Data()
+	if err != nil {
+		return err
+	}
+	err = verifier.Verify(ctx, rsp.rsp, body)
+	if err == nil {
+		return nil
+	}
+	var vErr *VerificationError
+	if !errors.As(err, &vErr) {
+		vErr = &VerificationError{Err: err}
+	}
+	vErr.StatusCode = rsp.StatusCode()
+	return vErr
+}
+
+// SignatureVerifier 验证响应头中的签名，签名串默认为"时间戳\n随机数\nbody\n"
+type SignatureVerifier struct {
+	// SignatureHeader 签名header，默认X-Signature
+	SignatureHeader string
+	// KeyIDHeader 密钥ID header，默认X-Key-Id，响应没有该header时使用key ID为空的密钥
+	KeyIDHeader string
+	// TimestampHeader 时间戳（unix秒）header，默认X-Timestamp
+	TimestampHeader string
+	// NonceHeader 随机数header，默认X-Nonce
+	NonceHeader string
+	// MaxSkew 时间戳与当前时间允许的最大偏差，0表示不检查
+	MaxSkew time.Duration
+	// Message 构造签名串
+	Message func(timestamp, nonce string, body []byte) []byte
+	// Decode 签名解码，默认标准base64
+	Decode func(sig string) ([]byte, error)
+	// Now 当前时间，默认time.Now
+	Now func() time.Time
+
+	verify func(keyID string, message, sig []byte) error
+}
+
+// NewHMACVerifier HMAC-SHA256签名验证，keys为key ID到密钥的映射
+func NewHMACVerifier(keys map[string][]byte) *SignatureVerifier {
+	return &SignatureVerifier{verify: func(keyID string, message, sig []byte) error {
+		var key, ok = keys[keyID]
+		if !ok {
+			return ErrUnknownKey
+		}
+		var mac = hmac.New(sha256.New, key)
+		_, _ = mac.Write(message)
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return ErrSignatureInvalid
+		}
+		return nil
+	}}
+}
+
+// NewRSAVerifier RSA-SHA256（PKCS#1 v1.5）签名验证，keys为key ID（例如证书序列号）到公钥的映射
+func NewRSAVerifier(keys map[string]*rsa.PublicKey) *SignatureVerifier {
+	return &SignatureVerifier{verify: func(keyID string, message, sig []byte) error {
+		var key, ok = keys[keyID]
+		if !ok {
+			return ErrUnknownKey
+		}
+		var digest = sha256.Sum256(message)
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) != nil {
+			return ErrSignatureInvalid
+		}
+		return nil
+	}}
+}
+
+func (v *SignatureVerifier) Verify(ctx context.Context, rsp *http.Response, body []byte) error {
+	var sigValue = rsp.Header.Get(headerOrDefault(v.SignatureHeader, "X-Signature"))
+	var keyID = rsp.Header.Get(headerOrDefault(v.KeyIDHeader, "X-Key-Id"))
+	if sigValue == "" {
+		return &VerificationError{KeyID: keyID, Err: ErrSignatureMissing}
+	}
+	var timestamp = rsp.Header.Get(headerOrDefault(v.TimestampHeader, "X-Timestamp"))
+	var nonce = rsp.Header.Get(headerOrDefault(v.NonceHeader, "X-Nonce"))
+	if v.MaxSkew > 0 {
+		var now = time.Now
+		if v.Now != nil {
+			now = v.Now
+		}
+		var sec, err = strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return &VerificationError{KeyID: keyID, Err: ErrTimestampSkew}
+		}
+		var skew = now().Sub(time.Unix(sec, 0))
+		if skew > v.MaxSkew || skew < -v.MaxSkew {
+			return &VerificationError{KeyID: keyID, Err: ErrTimestampSkew}
+		}
+	}
+	var decode = v.Decode
+	if decode == nil {
+		decode = base64.StdEncoding.DecodeString
+	}
+	var sig, err = decode(sigValue)
+	if err != nil {
+		return &VerificationError{KeyID: keyID, Err: ErrSignatureInvalid}
+	}
+	var message []byte
+	if v.Message != nil {
+		message = v.Message(timestamp, nonce, body)
+	} else {
+		message = make([]byte, 0, len(timestamp)+len(nonce)+len(body)+3)
+		message = append(message, timestamp+"\n"+nonce+"\n"...)
+		message = append(message, body...)
+		message = append(message, '\n')
+	}
+	err = v.verify(keyID, message, sig)
+	if err != nil {
+		return &VerificationError{KeyID: keyID, Err: err}
+	}
+	return nil
+}
+
+// ParseRSAPublicKeyPEM 解析PEM格式的RSA公钥，支持PUBLIC KEY、RSA PUBLIC KEY和CERTIFICATE
+func ParseRSAPublicKeyPEM(data []byte) (*rsa.PublicKey, error) {
+	var block, _ = pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("restgo: invalid PEM data")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key = cert.PublicKey
+	default:
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var rsaKey, ok = key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("restgo: not an RSA public key")
+	}
+	return rsaKey, nil
+}
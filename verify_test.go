@@ -0,0 +1,104 @@
+package restgo
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestResponseVerifier_HMAC(t *testing.T) {
+	var key = []byte("secret")
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body = []byte(`{"ok":true}`)
+		var ts = strconv.FormatInt(time.Now().Unix(), 10)
+		var mac = hmac.New(sha256.New, key)
+		_, _ = mac.Write([]byte(ts + "\nn1\n" + string(body) + "\n"))
+		w.Header().Set("X-Timestamp", ts)
+		w.Header().Set("X-Nonce", "n1")
+		w.Header().Set("X-Key-Id", "k1")
+		switch r.URL.Path {
+		case "/ok":
+			w.Header().Set("X-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		case "/tampered":
+			w.Header().Set("X-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+			body = []byte(`{"ok":false}`)
+		}
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	var verifier = NewHMACVerifier(map[string][]byte{"k1": key})
+	verifier.MaxSkew = time.Minute
+	var c = New(WithBaseURL(srv.URL), WithResponseVerifier(verifier))
+
+	var rsp, err = c.Get(context.Background(), "/ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct{ OK bool }
+	if err = rsp.JSONUnmarshal(&out); err != nil || !out.OK {
+		t.Errorf("unexpected body %v %v", out, err)
+	}
+	var cases = map[string]error{"/tampered": ErrSignatureInvalid, "/missing": ErrSignatureMissing}
+	for path, expect := range cases {
+		_, err = c.Get(context.Background(), path)
+		var vErr *VerificationError
+		if !errors.Is(err, ErrVerification) || !errors.Is(err, expect) || !errors.As(err, &vErr) || vErr.KeyID != "k1" {
+			t.Errorf("%s: expect %v, got %v", path, expect, err)
+		}
+	}
+	_, err = c.Do(context.Background(), NewRequest("GET", "/missing"), WithoutResponseVerify())
+	if err != nil {
+		t.Errorf("expect verification disabled, got %v", err)
+	}
+}
+
+func TestResponseVerifier_RSA(t *testing.T) {
+	var priv, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var der, _ = x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	var pub *rsa.PublicKey
+	pub, err = ParseRSAPublicKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body = []byte("hello")
+		var digest = sha256.Sum256([]byte("1700000000\nn1\nhello\n"))
+		var sig, _ = rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, digest[:])
+		w.Header().Set("X-Timestamp", "1700000000")
+		w.Header().Set("X-Nonce", "n1")
+		w.Header().Set("X-Signature", base64.StdEncoding.EncodeToString(sig))
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	var c = New(WithBaseURL(srv.URL), WithResponseVerifier(NewRSAVerifier(map[string]*rsa.PublicKey{"": pub})))
+	var rsp IResponse
+	rsp, err = c.Get(context.Background(), "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data, _ = rsp.Data()
+	if string(data) != "hello" {
+		t.Errorf("expect hello, got %s", data)
+	}
+	var other, _ = rsa.GenerateKey(rand.Reader, 2048)
+	c = New(WithBaseURL(srv.URL), WithResponseVerifier(NewRSAVerifier(map[string]*rsa.PublicKey{"": &other.PublicKey})))
+	_, err = c.Get(context.Background(), "/")
+	if !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("expect invalid signature, got %v", err)
+	}
+}